@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/joho/godotenv"
 	"slide-cw-integration/internal/connectwise"
@@ -39,16 +42,20 @@ func runMapClients() error {
 		os.Getenv("CONNECTWISE_CLIENT_ID"),
 	)
 
+	// Let Ctrl+C abort a long-running fetch
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Get clients from both APIs
 	log.Println("Fetching Slide clients...")
-	slideClients, err := slideClient.GetClients()
+	slideClients, err := slideClient.GetClients(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get Slide clients: %w", err)
 	}
 	log.Printf("Found %d Slide clients", len(slideClients))
 
 	log.Println("Fetching ConnectWise clients...")
-	cwClients, err := cwClient.GetClients()
+	cwClients, err := cwClient.GetClients(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get ConnectWise clients: %w", err)
 	}
@@ -56,7 +63,7 @@ func runMapClients() error {
 
 	// Map clients
 	mappingService := mapping.NewService(db)
-	if err := mappingService.MapClients(slideClients, cwClients); err != nil {
+	if err := mappingService.MapClients(ctx, slideClients, cwClients); err != nil {
 		return fmt.Errorf("failed to map clients: %w", err)
 	}
 
@@ -87,6 +94,9 @@ func showMappings() error {
 	}
 	defer db.Close()
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	fmt.Println("Current Client Mappings:")
 	fmt.Println("========================")
 
@@ -96,7 +106,7 @@ func showMappings() error {
 		os.Getenv("SLIDE_API_KEY"),
 	)
 
-	slideClients, err := slideClient.GetClients()
+	slideClients, err := slideClient.GetClients(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get Slide clients: %w", err)
 	}
@@ -104,7 +114,7 @@ func showMappings() error {
 	mappingService := mapping.NewService(db)
 
 	for _, client := range slideClients {
-		if mapping, err := mappingService.GetClientMapping(client.ID); err == nil && mapping != nil {
+		if mapping, err := mappingService.GetClientMapping(ctx, client.ID); err == nil && mapping != nil {
 			fmt.Printf("✓ %s → %s (CW ID: %d)\n",
 				mapping.SlideClientName, mapping.ConnectWiseName, mapping.ConnectWiseID)
 		} else {
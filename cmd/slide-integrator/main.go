@@ -6,6 +6,8 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	// Maintenance windows name IANA timezones; embed the database for hosts without one
+	_ "time/tzdata"
 
 	"github.com/joho/godotenv"
 	"slide-cw-integration/internal/alerts"
@@ -168,6 +170,17 @@ func runWebUI(port string) error {
 
 	// Initialize and start web server
 	webServer := web.NewServer(slideClient, cwClient, mappingService, db, port)
+	webServer.SetInstanceID(alertMonitor.InstanceID())
+
+	// Receive ticket updates from ConnectWise instead of polling every open ticket
+	if callbackURL := os.Getenv("CONNECTWISE_CALLBACK_URL"); callbackURL != "" {
+		webServer.EnableConnectWiseCallbacks(callbackURL, cwClient, alertMonitor)
+	}
+
+	// Accept alerts pushed by Slide; the monitor keeps polling for anything missed
+	if secret := os.Getenv("SLIDE_WEBHOOK_SECRET"); secret != "" {
+		webServer.EnableSlideWebhooks(secret, alertMonitor)
+	}
 
 	// Handle graceful shutdown
 	go func() {
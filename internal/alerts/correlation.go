@@ -0,0 +1,118 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"slide-cw-integration/internal/connectwise"
+	"slide-cw-integration/pkg/models"
+)
+
+// defaultCorrelationWindow applies when the configured window is unset
+const defaultCorrelationWindow = 60 * time.Minute
+
+// correlationKey returns the key shared by alerts that belong on one ticket, or ""
+// when correlation is off or the alert lacks the ID its mode groups on
+func correlationKey(config *models.TicketingConfig, alert *models.SlideAlert, clientID string) string {
+	var scope string
+	switch config.CorrelationMode {
+	case models.CorrelationAgent:
+		scope = alert.AgentID
+	case models.CorrelationDevice:
+		scope = alert.DeviceID
+	case models.CorrelationClient:
+		scope = clientID
+	default:
+		return ""
+	}
+
+	if scope == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%s:%s", config.CorrelationMode, scope, alert.Type)
+}
+
+// correlationWindow is how recently a correlated alert must have been ticketed for a new one to join it
+func correlationWindow(config *models.TicketingConfig) time.Duration {
+	if config.CorrelationWindowMinutes <= 0 {
+		return defaultCorrelationWindow
+	}
+	return time.Duration(config.CorrelationWindowMinutes) * time.Minute
+}
+
+// attachToIncident adds alert to the open ticket of a correlated alert seen within the
+// window, noting it on the ticket. It reports false if there is no such ticket.
+func (m *Monitor) attachToIncident(ctx context.Context, alert *models.SlideAlert, req *ticketRequest) (bool, error) {
+	if req.correlationKey == "" {
+		return false, nil
+	}
+
+	mappings, err := m.db.GetOpenAlertTicketMappingsByCorrelationKey(ctx, req.correlationKey)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up correlated tickets for alert %s: %w", alert.ID, err)
+	}
+
+	// Newest first, so only the first mapping can be inside the window
+	if len(mappings) == 0 || mappings[0].CreatedAt.Before(time.Now().Add(-correlationWindow(req.config))) {
+		return false, nil
+	}
+	ticketID := mappings[0].TicketID
+
+	if err := m.mappingService.SaveAlertTicketMapping(ctx, alert, ticketID, req.clientID, req.correlationKey); err != nil {
+		return false, fmt.Errorf("failed to attach alert %s to correlated ticket %d: %w", alert.ID, ticketID, err)
+	}
+
+	alertMessage := alert.GetParsedMessage()
+	if alertMessage == "" {
+		alertMessage = alert.Message
+	}
+
+	m.addTicketNote(ctx, ticketID, connectwise.NoteInternal, fmt.Sprintf(
+		"Agent %s raised a correlated Slide alert, tracked on this ticket.\n\nAlert: %s\nType: %s\nMessage: %s\nRaised: %s\n\nThis ticket closes once all %d correlated alerts resolve.",
+		req.agentName, alert.ID, alert.Type, alertMessage,
+		alert.Timestamp.UTC().Format(noteTimeFormat), len(mappings)+1))
+
+	log.Printf("Attached alert %s to correlated ConnectWise ticket %d", alert.ID, ticketID)
+	return true, nil
+}
+
+// keepTicketOpen closes only alert's mapping when other alerts on its ticket are still
+// open, so a correlated ticket closes with its last alert. It reports whether it did so.
+func (m *Monitor) keepTicketOpen(ctx context.Context, alert *models.SlideAlert, mapping *models.AlertTicketMapping) (bool, error) {
+	open, err := m.db.GetOpenAlertTicketMappingsByTicket(ctx, mapping.TicketID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check other alerts on ticket %d: %w", mapping.TicketID, err)
+	}
+
+	var remaining []models.AlertTicketMapping
+	var remainingIDs []string
+	for _, other := range open {
+		if other.AlertID != alert.ID {
+			remaining = append(remaining, other)
+			remainingIDs = append(remainingIDs, other.AlertID)
+		}
+	}
+	if len(remaining) == 0 {
+		return false, nil
+	}
+
+	if err := m.mappingService.CloseAlertTicketMapping(ctx, alert.ID); err != nil {
+		return false, fmt.Errorf("failed to update alert-ticket mapping in database: %w", err)
+	}
+
+	if strings.HasPrefix(mapping.CorrelationKey, stormKeyPrefix) {
+		m.addTicketNote(ctx, mapping.TicketID, connectwise.NoteInternal, fmt.Sprintf(
+			"Alert storm: Slide alert %s (%s) has resolved.\n\nStill affected (%d):\n%s",
+			alert.ID, alert.Type, len(remaining), stormRoster(remaining)))
+	} else {
+		m.addTicketNote(ctx, mapping.TicketID, connectwise.NoteInternal, fmt.Sprintf(
+			"Slide alert %s (%s) has resolved. This ticket stays open for the correlated alerts still outstanding: %s",
+			alert.ID, alert.Type, strings.Join(remainingIDs, ", ")))
+	}
+
+	log.Printf("Alert %s resolved; ticket %d stays open for %d correlated alerts", alert.ID, mapping.TicketID, len(remaining))
+	return true, nil
+}
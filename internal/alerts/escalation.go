@@ -0,0 +1,142 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"slide-cw-integration/internal/connectwise"
+	"slide-cw-integration/pkg/models"
+)
+
+// openTicket is an alert ticket as escalation sees it: when its first alert was
+// ticketed and the types of the alerts still open on it
+type openTicket struct {
+	openedAt   time.Time
+	alertTypes []string
+}
+
+// escalateTickets fires the escalation steps that have come due on open alert tickets.
+// A step is recorded once it has fired, so it acts on each ticket at most once.
+func (m *Monitor) escalateTickets(ctx context.Context) {
+	steps, err := m.db.GetEscalationSteps(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to load escalation steps: %v", err)
+		return
+	}
+
+	var enabled []models.EscalationStep
+	for _, step := range steps {
+		if step.Enabled {
+			enabled = append(enabled, step)
+		}
+	}
+	if len(enabled) == 0 {
+		return
+	}
+
+	mappings, err := m.db.GetOpenAlertTicketMappings(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to load open tickets for escalation: %v", err)
+		return
+	}
+
+	escalations, err := m.db.GetTicketEscalations(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to load fired escalation steps: %v", err)
+		return
+	}
+	fired := make(map[[2]int]bool, len(escalations))
+	for _, e := range escalations {
+		fired[[2]int{e.TicketID, e.StepID}] = true
+	}
+
+	// Mappings come oldest first, so the first one seen for a ticket is when it was opened
+	tickets := make(map[int]*openTicket)
+	var order []int
+	for _, mapping := range mappings {
+		ticket, ok := tickets[mapping.TicketID]
+		if !ok {
+			ticket = &openTicket{openedAt: mapping.CreatedAt}
+			tickets[mapping.TicketID] = ticket
+			order = append(order, mapping.TicketID)
+		}
+		ticket.alertTypes = append(ticket.alertTypes, mapping.AlertType)
+	}
+
+	for _, ticketID := range order {
+		if ctx.Err() != nil {
+			return
+		}
+
+		ticket := tickets[ticketID]
+		var due []*models.EscalationStep
+		for i := range enabled {
+			step := &enabled[i]
+			if fired[[2]int{ticketID, step.ID}] || !step.AppliesTo(ticket.alertTypes) {
+				continue
+			}
+			if time.Since(ticket.openedAt) >= time.Duration(step.AfterHours)*time.Hour {
+				due = append(due, step)
+			}
+		}
+
+		if len(due) > 0 {
+			m.escalateTicket(ctx, ticketID, ticket.openedAt, due)
+		}
+	}
+}
+
+// escalateTicket fires steps on a ticket in order, stopping at the first that fails so
+// it and the ones after it are tried again next cycle
+func (m *Monitor) escalateTicket(ctx context.Context, ticketID int, openedAt time.Time, steps []*models.EscalationStep) {
+	// A ticket a technician has closed is left for the closed-ticket sync
+	ticket, err := m.connectWise.GetTicket(ctx, ticketID)
+	if err != nil {
+		log.Printf("Warning: failed to get ticket %d for escalation: %v", ticketID, err)
+		return
+	}
+	if ticket.IsClosed() {
+		return
+	}
+
+	for _, step := range steps {
+		if err := m.connectWise.EscalateTicket(ctx, ticketID, step.PriorityName, step.OwnerID); err != nil {
+			log.Printf("Warning: failed to apply escalation step %q to ticket %d: %v", step.Name, ticketID, err)
+			return
+		}
+
+		m.addTicketNote(ctx, ticketID, connectwise.NoteInternal, escalationNote(step, openedAt))
+
+		if err := m.db.RecordTicketEscalation(ctx, ticketID, step.ID); err != nil {
+			log.Printf("Warning: failed to record escalation step %q on ticket %d: %v", step.Name, ticketID, err)
+		}
+
+		log.Printf("Escalated ticket %d: step %q (open %d+ hours)", ticketID, step.Name, step.AfterHours)
+	}
+}
+
+// escalationNote describes what a step changed on a ticket, followed by the step's own note
+func escalationNote(step *models.EscalationStep, openedAt time.Time) string {
+	lines := []string{fmt.Sprintf("Escalation \"%s\": this ticket has been open for over %d hours (since %s).",
+		step.Name, step.AfterHours, openedAt.UTC().Format(noteTimeFormat))}
+
+	if step.PriorityName != "" {
+		lines = append(lines, fmt.Sprintf("Priority raised to %s.", step.PriorityName))
+	}
+	if step.OwnerID != nil {
+		owner := step.OwnerName
+		if owner == "" {
+			owner = fmt.Sprintf("member %d", *step.OwnerID)
+		}
+		lines = append(lines, fmt.Sprintf("Reassigned to %s.", owner))
+	}
+
+	text := strings.Join(lines, "\n")
+	if step.Note != "" {
+		text += "\n\n" + step.Note
+	}
+	return text
+}
@@ -0,0 +1,73 @@
+package alerts
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"slide-cw-integration/internal/slide"
+	"slide-cw-integration/pkg/models"
+)
+
+// holdAlert reports whether alert should wait before being ticketed: it is inside its
+// type's grace period, or its agent is flapping and it is inside the longer flap grace.
+// Held alerts are looked at again every cycle, so one that clears meanwhile is just closed.
+func (m *Monitor) holdAlert(ctx context.Context, alert *models.SlideAlert) bool {
+	policy, err := m.db.GetAlertTypePolicy(ctx, alert.Type)
+	if err != nil {
+		log.Printf("Warning: failed to load policy for alert type %s, ticketing without a grace period: %v", alert.Type, err)
+		return false
+	}
+	if policy == nil {
+		return false
+	}
+
+	age := time.Since(alert.Timestamp)
+	if grace := time.Duration(policy.GraceMinutes) * time.Minute; age < grace {
+		log.Printf("Holding alert %s: %s is inside the %d minute grace period for %s",
+			alert.ID, age.Round(time.Second), policy.GraceMinutes, alert.Type)
+		return true
+	}
+
+	flapGrace := time.Duration(policy.FlapGraceMinutes) * time.Minute
+	if policy.FlapThreshold <= 0 || age >= flapGrace {
+		return false
+	}
+
+	raised, err := m.countAgentAlerts(ctx, alert, time.Duration(policy.FlapWindowHours)*time.Hour)
+	if err != nil {
+		log.Printf("Warning: failed to check whether agent %s is flapping: %v", alert.AgentID, err)
+		return false
+	}
+	if raised < policy.FlapThreshold {
+		return false
+	}
+
+	log.Printf("Holding alert %s: agent %s is flapping (%d %s alerts in %d hours), waiting %d minutes",
+		alert.ID, alert.AgentID, raised, alert.Type, policy.FlapWindowHours, policy.FlapGraceMinutes)
+	return true
+}
+
+// countAgentAlerts counts the alerts of alert's type its agent has raised within window,
+// resolved or not, including alert itself
+func (m *Monitor) countAgentAlerts(ctx context.Context, alert *models.SlideAlert, window time.Duration) (int, error) {
+	if alert.AgentID == "" {
+		return 0, nil
+	}
+
+	recent, err := m.slideClient.ListAlerts(ctx, slide.AlertListOptions{
+		CreatedAfter: time.Now().Add(-window),
+		DeviceID:     alert.DeviceID,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, other := range recent {
+		if other.AgentID == alert.AgentID && other.Type == alert.Type {
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,193 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// MonitorLease is the database lease a monitor must hold to process alerts
+	MonitorLease = "alert_monitor"
+
+	// leaseTTL is how long a lease lasts without renewal before another instance may take it
+	leaseTTL = 90 * time.Second
+
+	leaseRenewInterval = 30 * time.Second
+
+	// leaseHoldTime is how long after its last renewal a leader keeps working. It stops a
+	// renew interval before the lease runs out, so no other instance can take over first.
+	leaseHoldTime = leaseTTL - leaseRenewInterval
+)
+
+// leaseState tracks whether this instance currently holds the monitor lease
+type leaseState struct {
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// lastRenewed is when the last successful renewal was attempted
+	lastRenewed time.Time
+
+	// expiry steps down once the lease goes leaseHoldTime without renewal
+	expiry *time.Timer
+
+	// reconcile is set when the lease is (re)acquired, since the previous
+	// holder may have died part way through creating a ticket
+	reconcile atomic.Bool
+}
+
+func defaultInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown-host"
+	}
+	return fmt.Sprintf("%s/%d", host, os.Getpid())
+}
+
+// InstanceID identifies this process as a holder of the monitor lease
+func (m *Monitor) InstanceID() string {
+	return m.instanceID
+}
+
+// IsLeader reports whether this instance currently holds the monitor lease
+func (m *Monitor) IsLeader() bool {
+	return m.leaderContext() != nil
+}
+
+// leaseLoop renews the monitor lease (or waits to take it over) until ctx ends,
+// then releases it so a standby instance can take over straight away
+func (m *Monitor) leaseLoop(ctx context.Context) {
+	ticker := time.NewTicker(leaseRenewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.renewLease(ctx)
+		case <-ctx.Done():
+			m.dropLease()
+
+			releaseCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := m.db.ReleaseLease(releaseCtx, MonitorLease, m.instanceID); err != nil {
+				log.Printf("Warning: failed to release monitor lease: %v", err)
+			}
+			cancel()
+			return
+		}
+	}
+}
+
+// renewLease takes or renews the monitor lease and updates the leader context to match
+func (m *Monitor) renewLease(ctx context.Context) {
+	// The lease we write runs from no earlier than now, so count our hold from here rather
+	// than from when the database answered
+	attempted := time.Now()
+	held, err := m.db.AcquireLease(ctx, MonitorLease, m.instanceID, leaseTTL)
+
+	l := &m.lease
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err != nil {
+		// Keep working until expiry fires, short of the lease we last wrote running out
+		log.Printf("Warning: failed to renew monitor lease: %v", err)
+		return
+	}
+
+	hold := leaseHoldTime - time.Since(attempted)
+	switch {
+	case held && l.cancel == nil:
+		log.Printf("Acquired monitor lease as %s", m.instanceID)
+		l.ctx, l.cancel = context.WithCancel(ctx)
+		l.expiry = time.AfterFunc(hold, m.expireLease)
+		l.reconcile.Store(true)
+	case held:
+		l.expiry.Reset(hold)
+	case l.cancel != nil:
+		log.Printf("Monitor lease taken over by another instance, standing by")
+		l.stepDown()
+	}
+
+	if held {
+		l.lastRenewed = attempted
+	}
+}
+
+// expireLease stands by once the lease has gone unrenewed for leaseHoldTime
+func (m *Monitor) expireLease() {
+	l := &m.lease
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// A renewal may have landed just as the timer fired
+	if l.cancel == nil || time.Since(l.lastRenewed) < leaseHoldTime {
+		return
+	}
+
+	log.Printf("Monitor lease not renewed for %s, standing by", leaseHoldTime)
+	l.stepDown()
+}
+
+// dropLease stops treating this instance as the leader
+func (m *Monitor) dropLease() {
+	l := &m.lease
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.cancel != nil {
+		l.stepDown()
+	}
+}
+
+// stepDown cancels the leader context. Callers hold l.mu.
+func (l *leaseState) stepDown() {
+	l.cancel()
+	l.expiry.Stop()
+	l.ctx, l.cancel, l.expiry = nil, nil, nil
+}
+
+// leaderContext returns a context cancelled when the lease is lost, or nil if this instance doesn't hold it
+func (m *Monitor) leaderContext() context.Context {
+	m.lease.mu.Lock()
+	defer m.lease.mu.Unlock()
+	return m.lease.ctx
+}
+
+// asLeader runs fn for at most one check interval if this instance holds the lease.
+// fn's context is cancelled early if the lease is lost. It reports whether fn ran.
+func (m *Monitor) asLeader(ctx context.Context, fn func(ctx context.Context)) bool {
+	leaderCtx := m.leaderContext()
+	if leaderCtx == nil || ctx.Err() != nil {
+		return false
+	}
+
+	// A cycle must never overrun into the next one
+	runCtx, cancel := context.WithTimeout(leaderCtx, m.checkInterval)
+	defer cancel()
+
+	fn(runCtx)
+	return true
+}
+
+// standby reports whether pushed events should be left to another instance:
+// the monitor is running but doesn't hold the lease
+func (m *Monitor) standby() bool {
+	return m.elected.Load() && m.leaderContext() == nil
+}
+
+// reconcileIfNewLeader settles interrupted ticket creations once per lease acquisition
+func (m *Monitor) reconcileIfNewLeader(ctx context.Context) {
+	if !m.lease.reconcile.CompareAndSwap(true, false) {
+		return
+	}
+
+	if err := m.ReconcilePendingTickets(ctx); err != nil {
+		log.Printf("Error reconciling pending tickets: %v", err)
+		m.lease.reconcile.Store(true)
+	}
+}
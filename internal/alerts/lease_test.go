@@ -0,0 +1,87 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"slide-cw-integration/internal/mapping"
+)
+
+// standbyMonitor returns a second instance sharing e's APIs and database
+func standbyMonitor(e *testEnv, instanceID string) *Monitor {
+	m := NewMonitor(e.slide.Client(), e.cw.Client(), mapping.NewService(e.db), e.db)
+	m.instanceID = instanceID
+	return m
+}
+
+func TestLeaseHandover(t *testing.T) {
+	e := newTestEnv(t)
+	first := standbyMonitor(e, "first")
+	second := standbyMonitor(e, "second")
+
+	if err := first.Start(); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !first.IsLeader() {
+		if time.Now().After(deadline) {
+			first.Stop()
+			t.Fatal("first instance never took the lease")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	leaderCtx := first.leaderContext()
+
+	second.renewLease(e.ctx)
+	if second.IsLeader() {
+		first.Stop()
+		t.Fatal("second instance took the lease while the first held it")
+	}
+
+	// Stopping releases the lease, so the standby takes over on its next renewal
+	first.Stop()
+	if leaderCtx.Err() == nil {
+		t.Error("stopped leader's context was not cancelled")
+	}
+
+	second.renewLease(e.ctx)
+	if !second.IsLeader() {
+		t.Fatal("standby did not take over the released lease")
+	}
+	if !second.lease.reconcile.Load() {
+		t.Error("new leader was not asked to reconcile interrupted ticket creations")
+	}
+	second.dropLease()
+}
+
+func TestLeaseStepsDownBeforeExpiry(t *testing.T) {
+	e := newTestEnv(t)
+	m := standbyMonitor(e, "leader")
+
+	m.renewLease(e.ctx)
+	if !m.IsLeader() {
+		t.Fatal("did not take the free lease")
+	}
+	leaderCtx := m.leaderContext()
+
+	// A renewal only just missed keeps the instance working
+	m.lease.mu.Lock()
+	m.lease.lastRenewed = time.Now().Add(-leaseRenewInterval)
+	m.lease.mu.Unlock()
+	m.expireLease()
+	if !m.IsLeader() {
+		t.Fatal("stepped down with time left on the lease")
+	}
+
+	// By leaseHoldTime it stands by, a renew interval before another instance could take over
+	m.lease.mu.Lock()
+	m.lease.lastRenewed = time.Now().Add(-leaseHoldTime)
+	m.lease.mu.Unlock()
+	m.expireLease()
+	if m.IsLeader() {
+		t.Fatal("still leading after going leaseHoldTime without renewal")
+	}
+	if leaderCtx.Err() == nil {
+		t.Error("leader context was not cancelled on stepping down")
+	}
+}
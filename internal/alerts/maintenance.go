@@ -0,0 +1,78 @@
+package alerts
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"slide-cw-integration/pkg/models"
+)
+
+// holdForMaintenance reports whether an active maintenance window covers alert, recording
+// the alert against the window if so. Nothing else is kept: the alert stays unresolved in
+// Slide, so the first cycle after the window ends tickets it as usual.
+func (m *Monitor) holdForMaintenance(ctx context.Context, alert *models.SlideAlert) bool {
+	window := m.activeMaintenanceWindow(ctx, alert)
+	if window == nil {
+		return false
+	}
+
+	record := &models.MaintenanceAlert{
+		AlertID:   alert.ID,
+		WindowID:  window.ID,
+		AgentID:   alert.AgentID,
+		AlertType: alert.Type,
+	}
+	if err := m.db.RecordMaintenanceAlert(ctx, record); err != nil {
+		log.Printf("Warning: failed to record alert %s against maintenance window %d: %v", alert.ID, window.ID, err)
+	}
+
+	log.Printf("Holding alert %s: maintenance window %q covers %s %s", alert.ID, window.Name, window.Scope, window.ScopeID)
+	return true
+}
+
+// activeMaintenanceWindow returns an active window covering alert's client, device or agent, or nil
+func (m *Monitor) activeMaintenanceWindow(ctx context.Context, alert *models.SlideAlert) *models.MaintenanceWindow {
+	windows, err := m.db.GetMaintenanceWindows(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to load maintenance windows, ticketing as usual: %v", err)
+		return nil
+	}
+
+	now := time.Now()
+	var clientID string
+	for i := range windows {
+		window := &windows[i]
+		active, err := window.ActiveAt(now)
+		if err != nil {
+			log.Printf("Warning: skipping maintenance window %d: %v", window.ID, err)
+			continue
+		}
+		if !active {
+			continue
+		}
+
+		var target string
+		switch window.Scope {
+		case models.MaintenanceAgent:
+			target = alert.AgentID
+		case models.MaintenanceDevice:
+			target = alert.DeviceID
+		case models.MaintenanceClient:
+			// Only look the client up once, and only if a client window is active
+			if clientID == "" {
+				if clientID, err = m.resolveAlertClient(ctx, alert); err != nil {
+					log.Printf("Warning: failed to resolve client for alert %s: %v", alert.ID, err)
+					continue
+				}
+			}
+			target = clientID
+		}
+
+		if target != "" && target == window.ScopeID {
+			return window
+		}
+	}
+
+	return nil
+}
@@ -1,156 +1,427 @@
 package alerts
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"slide-cw-integration/internal/connectwise"
 	"slide-cw-integration/internal/database"
+	"slide-cw-integration/internal/httpclient"
 	"slide-cw-integration/internal/mapping"
 	"slide-cw-integration/internal/slide"
 	"slide-cw-integration/pkg/models"
 )
 
+// noteTimeFormat is how timestamps are written in ticket notes
+const noteTimeFormat = "2006-01-02 15:04:05 MST"
+
+// resolvedAlertLookback is how far before an open ticket mapping we look for its resolved
+// alert when the mapping predates recording when the alert was raised
+const resolvedAlertLookback = 24 * time.Hour
+
+// SlideAPI is the subset of the Slide API the monitor depends on.
+// *slide.Client satisfies it; tests and alternate backends can supply their own.
+type SlideAPI interface {
+	ListAlerts(ctx context.Context, opts slide.AlertListOptions) ([]models.SlideAlert, error)
+	GetAlert(ctx context.Context, alertID string) (*models.SlideAlert, error)
+	ListBackups(ctx context.Context, opts slide.BackupListOptions) ([]models.SlideBackup, error)
+	ListReplications(ctx context.Context, opts slide.ReplicationListOptions) ([]models.SlideReplication, error)
+	GetDevices(ctx context.Context) ([]models.SlideDevice, error)
+	GetDevice(ctx context.Context, deviceID string) (*models.SlideDevice, error)
+	GetAgent(ctx context.Context, agentID string) (*models.SlideAgent, error)
+	GetClients(ctx context.Context) ([]models.SlideClient, error)
+	CloseAlert(ctx context.Context, alertID string) error
+}
+
+// PSA is the subset of the ticketing system the monitor depends on.
+// *connectwise.Client satisfies it.
+type PSA interface {
+	CreateTicketWithConfig(ctx context.Context, companyID int, summary, description string, config *models.TicketingConfig) (*models.ConnectWiseTicket, error)
+	GetTicket(ctx context.Context, ticketID int) (*models.ConnectWiseTicket, error)
+	CloseTicket(ctx context.Context, ticketID int) error
+	UpdateTicket(ctx context.Context, ticketID int, status string) error
+	GetStatuses(ctx context.Context, boardID int) ([]models.ConnectWiseStatus, error)
+	EscalateTicket(ctx context.Context, ticketID int, priorityName string, ownerID *int) error
+	AddTicketNote(ctx context.Context, ticketID int, text string, noteType connectwise.NoteType) error
+	SetResolvedStatuses(statuses []models.BoardResolvedStatus)
+	FindTicketByNote(ctx context.Context, companyID int, since time.Time, text string) (*models.ConnectWiseTicket, error)
+}
+
+var (
+	_ SlideAPI = (*slide.Client)(nil)
+	_ PSA      = (*connectwise.Client)(nil)
+)
+
 type Monitor struct {
-	slideClient     *slide.Client
-	connectWise     *connectwise.Client
-	mappingService  *mapping.Service
-	db              *database.DB
-	checkInterval   time.Duration
-	stopChan        chan bool
+	slideClient       SlideAPI
+	connectWise       PSA
+	mappingService    *mapping.Service
+	db                *database.DB
+	checkInterval     time.Duration
+	// reconcileInterval (a time.Duration) spaces out the full closed-ticket poll
+	// when ticket updates arrive by callback; zero polls every cycle
+	reconcileInterval atomic.Int64
+	lastReconcile     time.Time
+	// alertMu serialises alert handling between the polling loop and pushed
+	// webhook events so the same alert can't be ticketed twice
+	alertMu           sync.Mutex
+	// instanceID identifies this process in the monitor lease; only the
+	// lease holder processes alerts once Start has been called
+	instanceID        string
+	elected           atomic.Bool
+	lease             leaseState
+	// storm counts the tickets opened this cycle for alert storm protection
+	storm             stormCounter
+	// resolvers decide when alerts have cleared, keyed by lower-cased alert type
+	resolvers         map[string]Resolver
+	cancel            context.CancelFunc
+	done              chan struct{}
 }
 	//adding debug timing - 2 minutes
-func NewMonitor(slideClient *slide.Client, connectWise *connectwise.Client, mappingService *mapping.Service, db *database.DB) *Monitor {
-	return &Monitor{
+func NewMonitor(slideClient SlideAPI, connectWise PSA, mappingService *mapping.Service, db *database.DB) *Monitor {
+	m := &Monitor{
 		slideClient:    slideClient,
 		connectWise:    connectWise,
 		mappingService: mappingService,
 		db:             db,
 		checkInterval:  5 * time.Minute, // Check every 5 minutes
-		stopChan:       make(chan bool),
+		instanceID:     defaultInstanceID(),
+		done:           make(chan struct{}),
 	}
+	m.registerDefaultResolvers()
+	return m
 }
 
 func (m *Monitor) Start() error {
 	log.Println("Starting alert monitor...")
 
-	go m.monitorLoop()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.elected.Store(true)
+
+	go m.monitorLoop(ctx)
 
 	return nil
 }
 
+// Stop cancels any in-flight poll and waits for the monitor loop to exit
 func (m *Monitor) Stop() {
-	close(m.stopChan)
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	<-m.done
 }
 
-func (m *Monitor) monitorLoop() {
+func (m *Monitor) monitorLoop(ctx context.Context) {
+	defer close(m.done)
+
+	// Only the lease holder processes alerts; other instances stand by
+	m.renewLease(ctx)
+	leaseDone := make(chan struct{})
+	go func() {
+		defer close(leaseDone)
+		m.leaseLoop(ctx)
+	}()
+	defer func() { <-leaseDone }()
+
+	// Settle ticket creations a previous run was interrupted in before polling creates duplicates
+	m.asLeader(ctx, m.reconcileIfNewLeader)
+
 	ticker := time.NewTicker(m.checkInterval)
 	defer ticker.Stop()
 
+	queueTicker := time.NewTicker(workQueueInterval)
+	defer queueTicker.Stop()
+
 	for {
 		select {
+		case <-queueTicker.C:
+			m.asLeader(ctx, func(queueCtx context.Context) {
+				m.reconcileIfNewLeader(queueCtx)
+				if err := m.ProcessWorkQueue(queueCtx); err != nil {
+					log.Printf("Error processing work queue: %v", err)
+				}
+			})
 		case <-ticker.C:
-			if err := m.processAlerts(); err != nil {
-				log.Printf("Error processing alerts: %v", err)
+			if !m.asLeader(ctx, func(cycleCtx context.Context) {
+				if err := m.processAlerts(cycleCtx); err != nil {
+					log.Printf("Error processing alerts: %v", err)
+				}
+			}) {
+				log.Printf("Standing by - another instance holds the monitor lease")
 			}
-		case <-m.stopChan:
+		case <-ctx.Done():
 			log.Println("Alert monitor stopped")
 			return
 		}
 	}
 }
 
-func (m *Monitor) processAlerts() error {
+// SetReconcileInterval makes the monitor poll every open ticket only once per interval
+// instead of every cycle, for when ConnectWise callbacks deliver ticket updates.
+// Zero restores polling every cycle. Safe to call while the monitor is running.
+func (m *Monitor) SetReconcileInterval(interval time.Duration) {
+	m.reconcileInterval.Store(int64(interval))
+}
+
+// RunOnce performs a single polling cycle synchronously, as the background loop would
+func (m *Monitor) RunOnce(ctx context.Context) error {
+	return m.processAlerts(ctx)
+}
+
+func (m *Monitor) processAlerts(ctx context.Context) error {
 	log.Println("Checking for alerts...")
+	m.storm.reset()
 
-	alerts, err := m.slideClient.GetAlerts()
+	// Pick up any resolved status changes made in the web UI since the last cycle
+	m.refreshResolvedStatuses(ctx)
+
+	alerts, err := m.fetchAlerts(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get alerts: %w", err)
+		return err
 	}
 	// Removed skipped alerts. Was causing issues with closure from slide.
 	for _, alert := range alerts {
-		if alert.Resolved {
-			log.Printf("Alert %s is resolved in Slide, checking if CW ticket needs closing...", alert.ID)
-			// Check if there's a corresponding CW ticket that needs to be closed
-			if err := m.processResolvedAlert(&alert); err != nil {
-				log.Printf("Error processing resolved alert %s: %v", alert.ID, err)
-			}
-			continue
+		// Stop promptly if the monitor is shutting down or the cycle ran out of time
+		if err := ctx.Err(); err != nil {
+			return err
 		}
 
-		log.Printf("Processing unresolved alert: %s (Resolved field: %t)", alert.ID, alert.Resolved)
-		if err := m.handleAlert(&alert); err != nil {
-			log.Printf("Error handling alert %s: %v", alert.ID, err)
+		m.dispatchAlert(ctx, &alert)
+	}
+	m.clearSkippedCreations(ctx, alerts)
+
+	// Escalate alert tickets that have been open too long
+	m.escalateTickets(ctx)
+
+	// Check for manually closed ConnectWise tickets and close corresponding Slide alerts.
+	// With callbacks enabled this is only a periodic safety net for missed deliveries.
+	if interval := time.Duration(m.reconcileInterval.Load()); interval == 0 || time.Since(m.lastReconcile) >= interval {
+		if err := m.processClosedTickets(ctx); err != nil {
+			log.Printf("Error processing closed tickets: %v", err)
+		} else {
+			m.lastReconcile = time.Now()
 		}
 	}
 
-	// Check for manually closed ConnectWise tickets and close corresponding Slide alerts
-	if err := m.processClosedTickets(); err != nil {
-		log.Printf("Error processing closed tickets: %v", err)
+	return nil
+}
+
+// HandleAlertEvent processes an alert pushed by a Slide webhook through the same
+// pipeline as polled alerts. Polling still picks up anything a webhook missed.
+func (m *Monitor) HandleAlertEvent(ctx context.Context, alert *models.SlideAlert) error {
+	if alert.ID == "" {
+		return fmt.Errorf("alert event has no alert ID")
+	}
+
+	if m.standby() {
+		log.Printf("Leaving Slide webhook for alert %s to the instance holding the monitor lease", alert.ID)
+		return nil
 	}
 
+	log.Printf("Received Slide webhook for alert %s (resolved: %t)", alert.ID, alert.Resolved)
+
+	m.refreshResolvedStatuses(ctx)
+	m.dispatchAlert(ctx, alert)
 	return nil
 }
 
-func (m *Monitor) handleAlert(alert *models.SlideAlert) error {
+// dispatchAlert closes the ticket of a resolved alert or makes sure an unresolved one is ticketed
+func (m *Monitor) dispatchAlert(ctx context.Context, alert *models.SlideAlert) {
+	m.alertMu.Lock()
+	defer m.alertMu.Unlock()
+
+	if alert.Resolved {
+		log.Printf("Alert %s is resolved in Slide, checking if CW ticket needs closing...", alert.ID)
+		// Check if there's a corresponding CW ticket that needs to be closed
+		if err := m.processResolvedAlert(ctx, alert); err != nil {
+			log.Printf("Error processing resolved alert %s: %v", alert.ID, err)
+		}
+		return
+	}
+
+	log.Printf("Processing unresolved alert: %s (Resolved field: %t)", alert.ID, alert.Resolved)
+	if err := m.handleAlert(ctx, alert); err != nil {
+		log.Printf("Error handling alert %s: %v", alert.ID, err)
+	}
+}
+
+// refreshResolvedStatuses hands the admin-chosen per-board resolved statuses to the PSA client
+func (m *Monitor) refreshResolvedStatuses(ctx context.Context) {
+	statuses, err := m.db.GetBoardResolvedStatuses(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to load board resolved statuses, keeping previous: %v", err)
+		return
+	}
+	m.connectWise.SetResolvedStatuses(statuses)
+}
+
+// fetchAlerts asks Slide only for the alerts this cycle can act on: every unresolved
+// alert, plus resolved alerts recent enough to still have an open ticket mapping
+func (m *Monitor) fetchAlerts(ctx context.Context) ([]models.SlideAlert, error) {
+	unresolved := false
+	alerts, err := m.slideClient.ListAlerts(ctx, slide.AlertListOptions{Resolved: &unresolved})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unresolved alerts: %w", err)
+	}
+
+	openMappings, err := m.db.GetOpenAlertTicketMappings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open alert-ticket mappings: %w", err)
+	}
+
+	if len(openMappings) == 0 {
+		return alerts, nil
+	}
+
+	// Look back to the oldest open mapping's alert, which may have been raised long before
+	// its ticket was created. The minute of slack covers Slide filtering by whole seconds.
+	resolved := true
+	createdAfter := time.Now()
+	for _, mapping := range openMappings {
+		raisedAfter := mapping.CreatedAt.Add(-resolvedAlertLookback)
+		if mapping.AlertCreatedAt != nil {
+			raisedAfter = mapping.AlertCreatedAt.Add(-time.Minute)
+		}
+		if raisedAfter.Before(createdAfter) {
+			createdAfter = raisedAfter
+		}
+	}
+	resolvedAlerts, err := m.slideClient.ListAlerts(ctx, slide.AlertListOptions{
+		Resolved:     &resolved,
+		CreatedAfter: createdAfter,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resolved alerts: %w", err)
+	}
+
+	log.Printf("Fetched %d unresolved and %d recently resolved alerts", len(alerts), len(resolvedAlerts))
+	return append(alerts, resolvedAlerts...), nil
+}
+
+func (m *Monitor) handleAlert(ctx context.Context, alert *models.SlideAlert) error {
 	clientID := alert.GetParsedClientID()
 	log.Printf("Processing alert: %s for client %s", alert.ID, clientID)
 
-	// Check if alert is resolved by checking backup status
-	if m.isAlertResolved(alert) {
-		log.Printf("Alert %s is resolved, closing...", alert.ID)
-		return m.closeAlert(alert)
+	if active, err := m.applyResolution(ctx, alert); !active {
+		return err
 	}
 
 	// Check if we already have a ticket for this alert
 	// If not, create one
-	return m.ensureTicketExists(alert)
+	return m.ensureTicketExists(ctx, alert)
 }
 
-func (m *Monitor) isAlertResolved(alert *models.SlideAlert) bool {
-	// For backup-related alerts, check if a successful backup completed after alert timestamp
-	if alert.Type == "backup_failed" || alert.Type == "backup_error" {
-		backups, err := m.slideClient.GetBackups()
-		if err != nil {
-			log.Printf("Error getting backups to check resolution: %v", err)
-			return false
-		}
-
-		// Find successful backups for this agent after the alert timestamp
-		for _, backup := range backups {
-			if backup.AgentID == alert.AgentID &&
-				backup.Success &&
-				backup.CompletedAt != nil &&
-				backup.CompletedAt.After(alert.Timestamp) {
-				log.Printf("Found successful backup %s for agent %s after alert %s", backup.ID, alert.AgentID, alert.ID)
-				return true
-			}
-		}
+// applyResolution checks whether the alert's resolver finds its problem has cleared, and
+// closes the alert or holds it for verification if so. It reports whether the alert is
+// still active and needs a ticket.
+func (m *Monitor) applyResolution(ctx context.Context, alert *models.SlideAlert) (bool, error) {
+	resolution, err := m.isAlertResolved(ctx, alert)
+	switch {
+	case err != nil:
+		log.Printf("Error checking whether alert %s has resolved: %v", alert.ID, err)
+	case resolution != nil && resolution.Pending:
+		return false, m.awaitVerification(ctx, alert, resolution)
+	case resolution != nil:
+		log.Printf("Alert %s is resolved, closing...", alert.ID)
+		return false, m.closeAlert(ctx, alert, resolution)
+	default:
+		m.cancelVerification(ctx, alert)
+	}
+	return true, nil
+}
+
+// isAlertResolved asks the resolver registered for the alert's type whether its underlying
+// problem has cleared, returning the evidence for the ticket's resolution note, or nil if
+// it has not
+func (m *Monitor) isAlertResolved(ctx context.Context, alert *models.SlideAlert) (*models.AlertResolution, error) {
+	resolver := m.resolverFor(alert.Type)
+	if resolver == nil {
+		return nil, nil
+	}
+
+	resolution, err := resolver.Resolve(ctx, alert)
+	if err != nil || resolution == nil {
+		return nil, err
 	}
 
-	return false
+	log.Printf("Alert %s resolved by %s check: %s", alert.ID, resolution.Resolver, resolution.Evidence)
+	return resolution, nil
 }
 
-func (m *Monitor) closeAlert(alert *models.SlideAlert) error {
+func (m *Monitor) closeAlert(ctx context.Context, alert *models.SlideAlert, resolution *models.AlertResolution) error {
+	// A resolved alert needs no ticket, even if creating one is waiting for a retry
+	m.discardWork(ctx, models.WorkCreateTicket, alert.ID)
+
+	if m.isQueued(ctx, models.WorkCloseAlert, alert.ID) {
+		log.Printf("Closing alert %s is queued for retry", alert.ID)
+		return nil
+	}
+
 	// Close alert in Slide API
-	if err := m.slideClient.CloseAlert(alert.ID); err != nil {
+	if err := m.slideClient.CloseAlert(ctx, alert.ID); err != nil {
+		m.enqueueWork(ctx, models.WorkItem{Action: models.WorkCloseAlert, AlertID: alert.ID}, err)
 		return fmt.Errorf("failed to close alert in Slide: %w", err)
 	}
 
 	// Close corresponding ticket in ConnectWise if it exists
-	mapping, err := m.mappingService.GetAlertTicketMapping(alert.ID)
+	mapping, err := m.mappingService.GetAlertTicketMapping(ctx, alert.ID)
+	if err == nil && mapping != nil {
+		if err := m.db.SetAlertResolution(ctx, alert.ID, resolution.Evidence); err != nil {
+			log.Printf("Warning: failed to record resolution of alert %s: %v", alert.ID, err)
+		}
+		if mapping.VerifyingSince != nil {
+			if err := m.db.SetAlertVerifying(ctx, alert.ID, nil); err != nil {
+				log.Printf("Warning: failed to clear pending verification of alert %s: %v", alert.ID, err)
+			}
+		}
+	}
+	if err == nil && mapping != nil && mapping.ClosedAt == nil &&
+		m.syncPolicy(ctx, mapping.ClientID) == models.SyncConnectWiseAuthoritative {
+		m.noteResolvedInSlide(ctx, alert, mapping,
+			fmt.Sprintf("resolved automatically by the %s check", resolution.Resolver), resolution.Evidence)
+		log.Printf("Alert %s closed successfully", alert.ID)
+		return nil
+	}
+	if err == nil && mapping != nil && mapping.ClosedAt == nil {
+		// A correlated ticket stays open until its last alert resolves
+		if kept, err := m.keepTicketOpen(ctx, alert, mapping); err != nil {
+			log.Printf("Leaving ConnectWise ticket %d open: %v", mapping.TicketID, err)
+			mapping = nil
+		} else if kept {
+			mapping = nil
+		}
+	}
 	if err == nil && mapping != nil {
-		if err := m.connectWise.CloseTicket(mapping.TicketID); err != nil {
+		if mapping.ClosedAt == nil {
+			m.addTicketNote(ctx, mapping.TicketID, connectwise.NoteResolution, fmt.Sprintf(
+				"Slide alert %s (%s) was resolved automatically by the %s check.\nEvidence: %s\nClosing this ticket.",
+				alert.ID, alert.Type, resolution.Resolver, resolution.Evidence))
+		}
+
+		if err := m.connectWise.CloseTicket(ctx, mapping.TicketID); err != nil && !isNotFound(err) {
+			// The work queue closes the ticket and then the mapping
 			log.Printf("Failed to close ConnectWise ticket %d: %v", mapping.TicketID, err)
+			m.enqueueWork(ctx, models.WorkItem{
+				Action:   models.WorkCloseTicket,
+				AlertID:  alert.ID,
+				TicketID: mapping.TicketID,
+			}, err)
 		} else {
 			log.Printf("Closed ConnectWise ticket %d for alert %s", mapping.TicketID, alert.ID)
-		}
 
-		// Mark the mapping as closed in database
-		if err := m.mappingService.CloseAlertTicketMapping(alert.ID); err != nil {
-			log.Printf("Failed to update alert-ticket mapping in database: %v", err)
+			// Mark the mapping as closed in database
+			if err := m.mappingService.CloseAlertTicketMapping(ctx, alert.ID); err != nil {
+				log.Printf("Failed to update alert-ticket mapping in database: %v", err)
+			}
 		}
 	}
 
@@ -158,9 +429,12 @@ func (m *Monitor) closeAlert(alert *models.SlideAlert) error {
 	return nil
 }
 
-func (m *Monitor) processResolvedAlert(alert *models.SlideAlert) error {
+func (m *Monitor) processResolvedAlert(ctx context.Context, alert *models.SlideAlert) error {
+	// A resolved alert needs no ticket, even if creating one is waiting for a retry
+	m.discardWork(ctx, models.WorkCreateTicket, alert.ID)
+
 	// Check if there's a ticket mapping for this resolved alert 
-	mapping, err := m.mappingService.GetAlertTicketMapping(alert.ID)
+	mapping, err := m.mappingService.GetAlertTicketMapping(ctx, alert.ID)
 	if err != nil {
 		return fmt.Errorf("failed to get alert-ticket mapping: %w", err)
 	}
@@ -177,16 +451,46 @@ func (m *Monitor) processResolvedAlert(alert *models.SlideAlert) error {
 		return nil
 	}
 
-	// Close the ConnectWise ticket
-	if err := m.connectWise.CloseTicket(mapping.TicketID); err != nil {
-		log.Printf("Failed to close ConnectWise ticket %d: %v", mapping.TicketID, err)
+	if m.isQueued(ctx, models.WorkCloseTicket, alert.ID) {
+		log.Printf("Closing ticket %d for alert %s is queued for retry", mapping.TicketID, alert.ID)
+		return nil
+	}
+
+	if m.syncPolicy(ctx, mapping.ClientID) == models.SyncConnectWiseAuthoritative {
+		// Already noted, whether here or when the alert was resolved automatically
+		if mapping.Resolution == "" || mapping.VerifyingSince != nil {
+			m.noteResolvedInSlide(ctx, alert, mapping, "resolved in Slide", "Resolved in Slide.")
+		}
+		return nil
+	}
+
+	// A correlated ticket stays open until its last alert resolves
+	if kept, err := m.keepTicketOpen(ctx, alert, mapping); err != nil || kept {
 		return err
 	}
 
-	log.Printf("Closed ConnectWise ticket %d for resolved alert %s", mapping.TicketID, alert.ID)
+	m.addTicketNote(ctx, mapping.TicketID, connectwise.NoteResolution, fmt.Sprintf(
+		"Slide alert %s (%s) was resolved in Slide.\nClosing this ticket.", alert.ID, alert.Type))
+
+	// Close the ConnectWise ticket
+	if err := m.connectWise.CloseTicket(ctx, mapping.TicketID); err != nil {
+		if !isNotFound(err) {
+			log.Printf("Failed to close ConnectWise ticket %d: %v", mapping.TicketID, err)
+			m.enqueueWork(ctx, models.WorkItem{
+				Action:   models.WorkCloseTicket,
+				AlertID:  alert.ID,
+				TicketID: mapping.TicketID,
+			}, err)
+			return err
+		}
+		// A deleted ticket needs no closing - just stop tracking it
+		log.Printf("ConnectWise ticket %d for resolved alert %s no longer exists", mapping.TicketID, alert.ID)
+	} else {
+		log.Printf("Closed ConnectWise ticket %d for resolved alert %s", mapping.TicketID, alert.ID)
+	}
 
 	// Mark the mapping as closed in database
-	if err := m.mappingService.CloseAlertTicketMapping(alert.ID); err != nil {
+	if err := m.mappingService.CloseAlertTicketMapping(ctx, alert.ID); err != nil {
 		log.Printf("Failed to update alert-ticket mapping in database: %v", err)
 		return err
 	}
@@ -194,9 +498,9 @@ func (m *Monitor) processResolvedAlert(alert *models.SlideAlert) error {
 	return nil
 }
 
-func (m *Monitor) ensureTicketExists(alert *models.SlideAlert) error {
+func (m *Monitor) ensureTicketExists(ctx context.Context, alert *models.SlideAlert) error {
 	// Check if ticket already exists for this alert
-	existing, err := m.mappingService.GetAlertTicketMapping(alert.ID)
+	existing, err := m.mappingService.GetAlertTicketMapping(ctx, alert.ID)
 	if err != nil {
 		return fmt.Errorf("failed to check existing ticket mapping: %w", err)
 	}
@@ -206,26 +510,85 @@ func (m *Monitor) ensureTicketExists(alert *models.SlideAlert) error {
 		return nil
 	}
 
+	// A failed creation is retried by the work queue with backoff, not every cycle, and one
+	// an admin discarded stays skipped until the alert resolves
+	if m.isQueued(ctx, models.WorkCreateTicket, alert.ID) {
+		log.Printf("Ticket creation for alert %s is queued for retry or was discarded", alert.ID)
+		return nil
+	}
+
+	return m.createTicket(ctx, alert)
+}
+
+// createTicket opens a ticket for alert unless it is held back, queueing the creation for
+// a retry if ConnectWise fails
+func (m *Monitor) createTicket(ctx context.Context, alert *models.SlideAlert) error {
+	// Planned work is expected to raise alerts; they are ticketed once it is over
+	if m.holdForMaintenance(ctx, alert) {
+		return nil
+	}
+
+	// Give a failure the chance to clear on its own before anyone is ticketed
+	if m.holdAlert(ctx, alert) {
+		return nil
+	}
+
+	req, err := m.prepareTicket(ctx, alert)
+	if err != nil {
+		return err
+	}
+
+	if err := m.submitTicket(ctx, alert, req); err != nil {
+		payload, _ := json.Marshal(alert)
+		m.enqueueWork(ctx, models.WorkItem{
+			Action:  models.WorkCreateTicket,
+			AlertID: alert.ID,
+			Payload: string(payload),
+		}, err)
+		return err
+	}
+
+	return nil
+}
+
+// ticketRequest is everything needed to create the ticket for an alert
+type ticketRequest struct {
+	companyID   int
+	// clientID and clientName are the Slide client the alert belongs to and its mapped name
+	clientID    string
+	clientName  string
+	summary     string
+	description string
+	config      *models.TicketingConfig
+	agentName   string
+	// rule is the routing rule that matched, if any
+	rule        *models.RoutingRule
+	// correlationKey is set when correlation is on; alerts sharing it share a ticket
+	correlationKey string
+}
+
+// prepareTicket works out the company, text and configuration for an alert's ticket
+func (m *Monitor) prepareTicket(ctx context.Context, alert *models.SlideAlert) (*ticketRequest, error) {
 	// Resolve the actual Slide client ID (not MSP account ID)
 	// For MSP accounts, alerts contain the MSP account_id, not the end client
-	realClientID, err := m.resolveAlertClient(alert)
+	realClientID, err := m.resolveAlertClient(ctx, alert)
 	if err != nil {
-		return fmt.Errorf("failed to resolve client for alert: %w", err)
+		return nil, fmt.Errorf("failed to resolve client for alert: %w", err)
 	}
 
 	// Get ConnectWise client ID for this alert's client
-	cwClientID, err := m.mappingService.GetConnectWiseClientID(realClientID)
+	cwClientID, err := m.mappingService.GetConnectWiseClientID(ctx, realClientID)
 	if err != nil {
-		return fmt.Errorf("failed to get ConnectWise client ID for alert (client: %s): %w", realClientID, err)
+		return nil, fmt.Errorf("failed to get ConnectWise client ID for alert (client: %s): %w", realClientID, err)
 	}
 
 	// Get ticketing configuration
-	config, err := m.db.GetTicketingConfig()
+	config, err := m.db.GetTicketingConfig(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get ticketing configuration: %w", err)
+		return nil, fmt.Errorf("failed to get ticketing configuration: %w", err)
 	}
 	if config == nil {
-		return fmt.Errorf("no ticketing configuration found - please run setup first")
+		return nil, fmt.Errorf("no ticketing configuration found - please run setup first")
 	}
 
 	// Get device and agent names from alert fields
@@ -235,7 +598,7 @@ func (m *Monitor) ensureTicketExists(alert *models.SlideAlert) error {
 
 	// Get the mapped ConnectWise client name (not the Slide account name)
 	var clientName string
-	mapping, err := m.mappingService.GetClientMapping(realClientID)
+	mapping, err := m.mappingService.GetClientMapping(ctx, realClientID)
 	if err != nil || mapping == nil {
 		log.Printf("Warning: no client mapping found for %s, using parsed name", realClientID)
 		clientName = alert.GetParsedClientName()
@@ -247,7 +610,7 @@ func (m *Monitor) ensureTicketExists(alert *models.SlideAlert) error {
 
 	// Fallback to resolving device name via API if not available
 	if deviceName == "" {
-		_, resolvedDeviceName, err := m.resolveNames(realClientID, alert.DeviceID)
+		_, resolvedDeviceName, err := m.resolveNames(ctx, realClientID, alert.DeviceID)
 		if err != nil {
 			log.Printf("Warning: failed to resolve device name for alert %s: %v", alert.ID, err)
 		} else {
@@ -266,39 +629,197 @@ func (m *Monitor) ensureTicketExists(alert *models.SlideAlert) error {
 		agentName = alert.AgentID
 	}
 
+	// The first matching routing rule can send the ticket elsewhere, or drop it
+	alertMessage := alert.GetParsedMessage()
+	if alertMessage == "" {
+		alertMessage = alert.Message
+	}
+	rule, err := m.matchRoutingRule(ctx, models.RoutingFacts{
+		AlertType:  alert.Type,
+		ClientID:   realClientID,
+		CompanyID:  cwClientID,
+		DeviceID:   alert.DeviceID,
+		DeviceName: deviceName,
+		AgentName:  agentName,
+		Message:    alertMessage,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rule != nil {
+		routed := rule.Apply(*config)
+		config = &routed
+	}
+
 	// Apply template substitutions
 	summary := m.applyTemplate(config.TicketSummary, alert, clientName, deviceName, agentName, agentHostname)
 	description := m.applyTemplate(config.TicketTemplate, alert, clientName, deviceName, agentName, agentHostname)
 
-	// Create ticket in ConnectWise using configuration
-	var ticket *models.ConnectWiseTicket
-	if config != nil {
-		ticket, err = m.connectWise.CreateTicketWithConfig(cwClientID, summary, description, config)
-	} else {
-		// Fallback to default ticket creation
-		ticket, err = m.connectWise.CreateTicket(cwClientID, summary, description)
+	// The tag lets a ticket created just before a crash be found and adopted
+	description = strings.TrimRight(description, "\n") + "\n\n" + alertTag(alert.ID)
+
+	return &ticketRequest{
+		companyID:   cwClientID,
+		clientID:    realClientID,
+		clientName:  clientName,
+		summary:     summary,
+		description: description,
+		config:      config,
+		agentName:   agentName,
+		rule:        rule,
+		correlationKey: correlationKey(config, alert, realClientID),
+	}, nil
+}
+
+// submitTicket creates the ticket in ConnectWise and records the alert-ticket mapping
+func (m *Monitor) submitTicket(ctx context.Context, alert *models.SlideAlert, req *ticketRequest) error {
+	if req.rule != nil && req.rule.Action == models.RouteIgnore {
+		log.Printf("Not ticketing alert %s: ignored by routing rule %q", alert.ID, req.rule.Name)
+		return nil
 	}
 
+	// An earlier attempt may have created the ticket and then died before recording it
+	adopted, err := m.adoptPendingTicket(ctx, alert.ID)
 	if err != nil {
+		return err
+	}
+	if adopted {
+		return nil
+	}
+
+	// A correlated alert joins the incident's existing ticket instead of opening another
+	attached, err := m.attachToIncident(ctx, alert, req)
+	if err != nil {
+		return err
+	}
+	if attached {
+		return nil
+	}
+
+	// An alert recurring soon after its ticket closed reopens that ticket, or references it from the new one
+	previous, err := m.recentlyClosedTicket(ctx, alert, req.config)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if previous != nil && req.config.ReopenMode == models.ReopenTicket {
+		reopened, err := m.reopenTicket(ctx, alert, req, previous)
+		if err != nil {
+			return err
+		}
+		if reopened {
+			return nil
+		}
+		previous = nil
+	}
+
+	// Past its cap a client's alerts roll up onto one storm ticket; past the circuit breaker nothing new is opened
+	held, err := m.applyStormProtection(ctx, alert, req)
+	if err != nil {
+		return err
+	}
+	if held {
+		return nil
+	}
+
+	// A storm ticket covers many agents, so it doesn't link back to one agent's old ticket
+	if strings.HasPrefix(req.correlationKey, stormKeyPrefix) {
+		previous = nil
+	}
+	description := req.description
+	if previous != nil {
+		description += previousTicketReference(previous)
+	}
+
+	// Mark the creation as in flight before calling ConnectWise
+	pending := &models.PendingTicket{
+		AlertID:   alert.ID,
+		CompanyID: req.companyID,
+		ClientID:  req.clientID,
+		AgentID:   alert.AgentID,
+		AlertType: alert.Type,
+		CorrelationKey: req.correlationKey,
+	}
+	if err := m.db.SavePendingTicket(ctx, pending); err != nil {
+		return fmt.Errorf("failed to record pending ticket for alert %s: %w", alert.ID, err)
+	}
+
+	// Create ticket in ConnectWise using configuration
+	ticket, err := m.connectWise.CreateTicketWithConfig(ctx, req.companyID, req.summary, description, req.config)
+	if err != nil {
+		// ConnectWise turning the request down means no ticket exists. Anything else
+		// (a timeout, a dropped connection, a gateway error) leaves the marker for reconciliation.
+		var apiErr *httpclient.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode < 500 {
+			m.clearPendingTicket(ctx, alert.ID)
+		}
 		return fmt.Errorf("failed to create ConnectWise ticket: %w", err)
 	}
 
-	// Save alert-ticket mapping in database
-	if err := m.mappingService.SaveAlertTicketMapping(alert.ID, ticket.ID); err != nil {
-		log.Printf("Failed to save alert-ticket mapping (alert: %s, ticket: %d): %v", alert.ID, ticket.ID, err)
+	// Look up the agent's other open tickets before this alert's mapping joins them
+	var earlierMappings []models.AlertTicketMapping
+	if alert.AgentID != "" {
+		earlierMappings, err = m.db.GetOpenAlertTicketMappingsByAgent(ctx, alert.AgentID)
+		if err != nil {
+			log.Printf("Warning: failed to look up open tickets for agent %s: %v", alert.AgentID, err)
+		}
+	}
+
+	// Save alert-ticket mapping in database. If this fails the pending marker stays,
+	// so the retry adopts this ticket rather than opening a duplicate.
+	if err := m.mappingService.SaveAlertTicketMapping(ctx, alert, ticket.ID, req.clientID, req.correlationKey); err != nil {
+		return fmt.Errorf("created ticket %d but failed to save alert-ticket mapping for alert %s: %w", ticket.ID, alert.ID, err)
 	}
+	m.clearPendingTicket(ctx, alert.ID)
+	m.storm.record(req.clientID)
 
 	log.Printf("Created ConnectWise ticket %d for alert %s using configuration", ticket.ID, alert.ID)
+
+	if previous != nil {
+		m.addTicketNote(ctx, previous.TicketID, connectwise.NoteInternal, fmt.Sprintf(
+			"Agent %s raised the same Slide alert again (%s), now tracked on ticket #%d.",
+			req.agentName, alert.ID, ticket.ID))
+	}
+
+	m.noteRecurrence(ctx, alert, req.agentName, ticket.ID, earlierMappings)
 	return nil
 }
 
+// noteRecurrence tells the agent's still-open tickets that it has raised another alert
+func (m *Monitor) noteRecurrence(ctx context.Context, alert *models.SlideAlert, agentName string, newTicketID int, earlier []models.AlertTicketMapping) {
+	alertMessage := alert.GetParsedMessage()
+	if alertMessage == "" {
+		alertMessage = alert.Message
+	}
+
+	noted := make(map[int]bool)
+	for _, mapping := range earlier {
+		if mapping.TicketID == newTicketID || noted[mapping.TicketID] {
+			continue
+		}
+		noted[mapping.TicketID] = true
+
+		m.addTicketNote(ctx, mapping.TicketID, connectwise.NoteInternal, fmt.Sprintf(
+			"Agent %s raised another Slide alert while this ticket is open.\n\nAlert: %s\nType: %s\nMessage: %s\nRaised: %s\nTracked in ticket #%d",
+			agentName, alert.ID, alert.Type, alertMessage,
+			alert.Timestamp.UTC().Format(noteTimeFormat), newTicketID))
+	}
+}
+
+// addTicketNote posts a note, logging rather than failing - a missing note
+// should never stop a ticket from being created or closed
+func (m *Monitor) addTicketNote(ctx context.Context, ticketID int, noteType connectwise.NoteType, text string) {
+	if err := m.connectWise.AddTicketNote(ctx, ticketID, text, noteType); err != nil {
+		log.Printf("Warning: failed to add note to ticket %d: %v", ticketID, err)
+	}
+}
+
 // resolveAlertClient determines the actual Slide client ID for an alert
 // For MSP accounts, alerts contain the MSP account_id, not the end client ID
 // This function uses device lookup and smart matching to find the real client
-func (m *Monitor) resolveAlertClient(alert *models.SlideAlert) (string, error) {
+func (m *Monitor) resolveAlertClient(ctx context.Context, alert *models.SlideAlert) (string, error) {
 	// Strategy 1: Try device ID → client ID lookup
 	if alert.DeviceID != "" {
-		devices, err := m.slideClient.GetDevices()
+		devices, err := m.slideClient.GetDevices(ctx)
 		if err != nil {
 			log.Printf("Warning: failed to get devices for client resolution: %v", err)
 		} else {
@@ -314,7 +835,7 @@ func (m *Monitor) resolveAlertClient(alert *models.SlideAlert) (string, error) {
 	// Strategy 2: Smart device name matching
 	deviceName := alert.GetParsedDeviceName()
 	if deviceName != "" {
-		clients, err := m.slideClient.GetClients()
+		clients, err := m.slideClient.GetClients(ctx)
 		if err != nil {
 			log.Printf("Warning: failed to get clients for name matching: %v", err)
 		} else {
@@ -391,12 +912,12 @@ func (m *Monitor) matchDeviceToClient(deviceName string, clients []models.SlideC
 }
 
 // resolveNames gets the human-readable names for client and device IDs
-func (m *Monitor) resolveNames(clientID, deviceID string) (clientName, deviceName string, err error) {
+func (m *Monitor) resolveNames(ctx context.Context, clientID, deviceID string) (clientName, deviceName string, err error) {
 	// Get client name from mapping service
-	mapping, err := m.mappingService.GetClientMapping(clientID)
+	mapping, err := m.mappingService.GetClientMapping(ctx, clientID)
 	if err != nil || mapping == nil {
 		// Try to get client from Slide API
-		clients, err := m.slideClient.GetClients()
+		clients, err := m.slideClient.GetClients(ctx)
 		if err != nil {
 			return "", "", fmt.Errorf("failed to get clients: %w", err)
 		}
@@ -416,7 +937,7 @@ func (m *Monitor) resolveNames(clientID, deviceID string) (clientName, deviceNam
 	}
 
 	// Get device name from Slide API
-	devices, err := m.slideClient.GetDevices()
+	devices, err := m.slideClient.GetDevices(ctx)
 	if err != nil {
 		return clientName, "", fmt.Errorf("failed to get devices: %w", err)
 	}
@@ -462,65 +983,154 @@ func (m *Monitor) applyTemplate(template string, alert *models.SlideAlert, clien
 
 // processClosedTickets checks for ConnectWise tickets that have been manually closed
 // and closes the corresponding Slide alerts
-func (m *Monitor) processClosedTickets() error {
+func (m *Monitor) processClosedTickets(ctx context.Context) error {
 	// Get all open alert-ticket mappings (where closed_at is NULL)
-	query := "SELECT alert_id, ticket_id FROM alert_ticket_mappings WHERE closed_at IS NULL"
-	rows, err := m.db.GetConn().Query(query)
+	openMappings, err := m.db.GetOpenAlertTicketMappings(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to query open alert-ticket mappings: %w", err)
 	}
-	defer rows.Close()
 
-	var openMappings []struct {
-		AlertID  string
-		TicketID int
+	// Several alerts can share a ticket - look each ticket up once
+	var ticketIDs []int
+	seen := make(map[int]bool)
+	for _, mapping := range openMappings {
+		if !seen[mapping.TicketID] {
+			seen[mapping.TicketID] = true
+			ticketIDs = append(ticketIDs, mapping.TicketID)
+		}
 	}
 
-	for rows.Next() {
-		var mapping struct {
-			AlertID  string
-			TicketID int
+	log.Printf("Checking %d open tickets (%d alert mappings) for closure", len(ticketIDs), len(openMappings))
+
+	// Check each ticket to see if it's been closed in ConnectWise
+	for _, ticketID := range ticketIDs {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-		if err := rows.Scan(&mapping.AlertID, &mapping.TicketID); err != nil {
-			log.Printf("Error scanning mapping row: %v", err)
-			continue
+
+		if err := m.syncTicket(ctx, ticketID); err != nil {
+			return err
 		}
-		openMappings = append(openMappings, mapping)
 	}
 
-	log.Printf("Checking %d open ticket mappings for closure", len(openMappings))
+	return nil
+}
 
-	// Check each ticket to see if it's been closed in ConnectWise
-	for _, mapping := range openMappings {
-		log.Printf("Checking ConnectWise ticket %d status for alert %s", mapping.TicketID, mapping.AlertID)
-		ticket, err := m.connectWise.GetTicket(mapping.TicketID)
-		if err != nil {
-			log.Printf("Error getting ticket %d status: %v", mapping.TicketID, err)
-			continue
-		}
+// HandleTicketUpdate reacts to a ConnectWise callback for ticketID, closing its Slide
+// alerts straight away if the ticket was closed. Tickets we don't track are ignored.
+func (m *Monitor) HandleTicketUpdate(ctx context.Context, ticketID int) error {
+	mappings, err := m.db.GetOpenAlertTicketMappingsByTicket(ctx, ticketID)
+	if err != nil {
+		return fmt.Errorf("failed to query mappings for ticket %d: %w", ticketID, err)
+	}
 
-		log.Printf("Ticket %d status: '%s', closedStatus: %t, IsClosed(): %t",
-			mapping.TicketID, ticket.Status.Name, ticket.Status.ClosedStatus, ticket.IsClosed())
+	if len(mappings) == 0 {
+		return nil
+	}
 
-		if ticket.IsClosed() {
-			log.Printf("Ticket %d is closed in ConnectWise (status: '%s', closedStatus: %t), closing corresponding Slide alert %s",
-				mapping.TicketID, ticket.Status.Name, ticket.Status.ClosedStatus, mapping.AlertID)
+	if m.standby() {
+		log.Printf("Leaving ConnectWise update for ticket %d to the instance holding the monitor lease", ticketID)
+		return nil
+	}
 
-			// Close the alert in Slide
-			if err := m.slideClient.CloseAlert(mapping.AlertID); err != nil {
-				log.Printf("Failed to close Slide alert %s: %v", mapping.AlertID, err)
-				continue
-			}
+	log.Printf("ConnectWise reported an update to ticket %d (%d open alert mappings)", ticketID, len(mappings))
+	return m.syncTicket(ctx, ticketID)
+}
 
-			// Mark the mapping as closed in database
-			if err := m.mappingService.CloseAlertTicketMapping(mapping.AlertID); err != nil {
-				log.Printf("Failed to update alert-ticket mapping for %s: %v", mapping.AlertID, err)
+// syncTicket re-reads ticketID's open mappings under alertMu before syncing them, so a
+// callback and the polling loop (or a Slide webhook) can't act on the same alerts at once
+func (m *Monitor) syncTicket(ctx context.Context, ticketID int) error {
+	m.alertMu.Lock()
+	defer m.alertMu.Unlock()
+
+	mappings, err := m.db.GetOpenAlertTicketMappingsByTicket(ctx, ticketID)
+	if err != nil {
+		return fmt.Errorf("failed to query mappings for ticket %d: %w", ticketID, err)
+	}
+	if len(mappings) == 0 {
+		return nil
+	}
+	return m.syncClosedTicket(ctx, ticketID, mappings)
+}
+
+// syncClosedTicket closes the Slide alerts behind ticketID if the ticket is closed (or gone)
+// in ConnectWise. Only errors that would affect every other ticket too are returned.
+func (m *Monitor) syncClosedTicket(ctx context.Context, ticketID int, mappings []models.AlertTicketMapping) error {
+	log.Printf("Checking ConnectWise ticket %d status for %d alerts", ticketID, len(mappings))
+	ticket, err := m.connectWise.GetTicket(ctx, ticketID)
+	if err != nil {
+		var apiErr *httpclient.APIError
+		if errors.As(err, &apiErr) {
+			switch {
+			case apiErr.IsUnauthorized():
+				// Every remaining lookup would fail the same way
+				return fmt.Errorf("ConnectWise rejected our credentials while checking ticket %d: %w", ticketID, err)
+			case apiErr.IsNotFound():
+				for _, mapping := range mappings {
+					log.Printf("Ticket %d no longer exists in ConnectWise, marking mapping for alert %s closed",
+						ticketID, mapping.AlertID)
+					if err := m.mappingService.CloseAlertTicketMapping(ctx, mapping.AlertID); err != nil {
+						log.Printf("Failed to update alert-ticket mapping for %s: %v", mapping.AlertID, err)
+					}
+				}
+				return nil
 			}
+		}
+		log.Printf("Error getting ticket %d status: %v", ticketID, err)
+		return nil
+	}
+
+	log.Printf("Ticket %d status: '%s', closedFlag: %t, IsClosed(): %t",
+		ticketID, ticket.Status.Name, ticket.ClosedFlag, ticket.IsClosed())
+
+	if !ticket.IsClosed() {
+		return nil
+	}
 
-			log.Printf("Successfully closed Slide alert %s (ticket %d was closed in ConnectWise)",
-				mapping.AlertID, mapping.TicketID)
+	var blocked []string
+	for _, mapping := range mappings {
+		if m.isQueued(ctx, models.WorkCloseAlert, mapping.AlertID) {
+			log.Printf("Closing Slide alert %s is queued for retry", mapping.AlertID)
+			continue
 		}
+
+		reason, err := m.closureBlocked(ctx, mapping)
+		if err != nil {
+			log.Printf("Leaving Slide alert %s open, couldn't check the sync policy: %v", mapping.AlertID, err)
+			continue
+		}
+		if reason != "" {
+			log.Printf("Sync policy keeps Slide alert %s open although ticket %d is closed: %s", mapping.AlertID, ticketID, reason)
+			blocked = append(blocked, reason)
+			continue
+		}
+
+		log.Printf("Ticket %d is closed in ConnectWise (status: '%s'), closing corresponding Slide alert %s",
+			ticketID, ticket.Status.Name, mapping.AlertID)
+
+		// Close the alert in Slide
+		if err := m.slideClient.CloseAlert(ctx, mapping.AlertID); err != nil {
+			log.Printf("Failed to close Slide alert %s: %v", mapping.AlertID, err)
+			m.enqueueWork(ctx, models.WorkItem{
+				Action:   models.WorkCloseAlert,
+				AlertID:  mapping.AlertID,
+				TicketID: ticketID,
+			}, err)
+			continue
+		}
+
+		// Mark the mapping as closed in database
+		if err := m.mappingService.CloseAlertTicketMapping(ctx, mapping.AlertID); err != nil {
+			log.Printf("Failed to update alert-ticket mapping for %s: %v", mapping.AlertID, err)
+		}
+
+		log.Printf("Successfully closed Slide alert %s (ticket %d was closed in ConnectWise)",
+			mapping.AlertID, ticketID)
+	}
+
+	if len(blocked) > 0 {
+		m.reopenBlockedTicket(ctx, ticket, blocked)
 	}
 
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,266 @@
+package alerts
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"slide-cw-integration/internal/connectwise/cwtest"
+	"slide-cw-integration/internal/database"
+	"slide-cw-integration/internal/mapping"
+	"slide-cw-integration/internal/slide/slidetest"
+	"slide-cw-integration/pkg/models"
+)
+
+// testEnv is a Monitor wired to fake Slide and ConnectWise APIs and a scratch database.
+// Slide client c1 (device d1) is mapped to ConnectWise company 5, whose tickets go on
+// board 1 with statuses New and Done.
+type testEnv struct {
+	ctx   context.Context
+	slide *slidetest.Server
+	cw    *cwtest.Server
+	db    *database.DB
+	mon   *Monitor
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	slideServer := slidetest.NewServer()
+	t.Cleanup(slideServer.Close)
+	cwServer := cwtest.NewServer()
+	t.Cleanup(cwServer.Close)
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	slideServer.AddClients(models.SlideClient{ID: "c1", Name: "Acme"})
+	slideServer.AddDevices(models.SlideDevice{ID: "d1", Name: "ACME-1", ClientID: "c1"})
+
+	cwServer.AddCompanies(models.ConnectWiseClient{ID: 5, Name: "Acme Corp"})
+	cwServer.AddBoard(models.ConnectWiseBoard{ID: 1, Name: "Help Desk"},
+		[]models.ConnectWiseStatus{{Name: "New"}, {Name: "Done", ClosedStatus: true}},
+		[]models.ConnectWiseType{{Name: "Backup"}})
+	cwServer.AddPriorities(models.ConnectWisePriority{ID: 3, Name: "Priority 3 - Normal"})
+
+	e := &testEnv{
+		ctx:   context.Background(),
+		slide: slideServer,
+		cw:    cwServer,
+		db:    db,
+	}
+
+	if err := db.SaveClientMapping(e.ctx, &models.ClientMapping{
+		SlideClientID:   "c1",
+		SlideClientName: "Acme",
+		ConnectWiseID:   5,
+		ConnectWiseName: "Acme Corp",
+	}); err != nil {
+		t.Fatalf("save client mapping: %v", err)
+	}
+	e.configure(t, func(config *models.TicketingConfig) {})
+
+	e.mon = NewMonitor(slideServer.Client(), cwServer.Client(), mapping.NewService(db), db)
+	return e
+}
+
+// configure saves the ticketing config after edit has adjusted the defaults
+func (e *testEnv) configure(t *testing.T, edit func(config *models.TicketingConfig)) {
+	t.Helper()
+
+	config := &models.TicketingConfig{
+		BoardID:        1,
+		BoardName:      "Help Desk",
+		StatusName:     "New",
+		PriorityName:   "Priority 3 - Normal",
+		TypeName:       "Backup",
+		TicketSummary:  "{{alert_type}} on {{agent_name}}",
+		TicketTemplate: "{{alert_message}}",
+		SyncPolicy:     models.SyncTwoWay,
+	}
+	edit(config)
+
+	if err := e.db.DeleteTicketingConfig(e.ctx); err != nil {
+		t.Fatalf("delete ticketing config: %v", err)
+	}
+	if err := e.db.SaveTicketingConfig(e.ctx, config); err != nil {
+		t.Fatalf("save ticketing config: %v", err)
+	}
+}
+
+func (e *testEnv) runOnce(t *testing.T) {
+	t.Helper()
+	if err := e.mon.RunOnce(e.ctx); err != nil {
+		t.Fatalf("RunOnce: %v", err)
+	}
+}
+
+// ticketFor returns the ConnectWise ticket mapped to alertID
+func (e *testEnv) ticketFor(t *testing.T, alertID string) cwtest.Ticket {
+	t.Helper()
+
+	mapping, err := e.db.GetAlertTicketMapping(e.ctx, alertID)
+	if err != nil || mapping == nil {
+		t.Fatalf("no ticket mapping for alert %s (err %v)", alertID, err)
+	}
+	ticket, ok := e.cw.Ticket(mapping.TicketID)
+	if !ok {
+		t.Fatalf("ticket %d for alert %s not found in ConnectWise", mapping.TicketID, alertID)
+	}
+	return ticket
+}
+
+// closedInSlide reports whether the monitor PATCHed alertID to resolved
+func (e *testEnv) closedInSlide(alertID string) bool {
+	for _, patch := range e.slide.Patches() {
+		if patch.AlertID == alertID && patch.Body["resolved"] == true {
+			return true
+		}
+	}
+	return false
+}
+
+// hasNote reports whether ticketID has a note containing text
+func (e *testEnv) hasNote(ticketID int, text string) bool {
+	for _, note := range e.cw.Notes(ticketID) {
+		if strings.Contains(note.Text, text) {
+			return true
+		}
+	}
+	return false
+}
+
+func testAlert(id, agentID, alertType string, raised time.Time) models.SlideAlert {
+	return models.SlideAlert{
+		ID:        id,
+		DeviceID:  "d1",
+		ClientID:  "c1",
+		AgentID:   agentID,
+		Type:      alertType,
+		Message:   "Backup of " + agentID + " failed",
+		Timestamp: raised,
+	}
+}
+
+func TestRunOnceTicketsAndClosesResolvedAlert(t *testing.T) {
+	e := newTestEnv(t)
+	raised := time.Now().Add(-time.Hour)
+	e.slide.AddAlerts(testAlert("a1", "agent1", "backup_failed", raised))
+
+	e.runOnce(t)
+
+	ticket := e.ticketFor(t, "a1")
+	if ticket.Company.ID != 5 || ticket.Board.Name != "Help Desk" || ticket.Status.Name != "New" {
+		t.Fatalf("ticket = company %d, board %q, status %q; want company 5 on Help Desk in New",
+			ticket.Company.ID, ticket.Board.Name, ticket.Status.Name)
+	}
+	if ticket.Summary != "backup_failed on agent1" {
+		t.Errorf("summary = %q", ticket.Summary)
+	}
+
+	// Nothing has changed, so the next cycle leaves everything alone
+	e.runOnce(t)
+	if n := len(e.cw.Tickets()); n != 1 {
+		t.Fatalf("got %d tickets after a quiet cycle, want 1", n)
+	}
+	if e.closedInSlide("a1") {
+		t.Fatal("alert closed before its backup succeeded")
+	}
+
+	completed := time.Now()
+	e.slide.AddBackups(models.SlideBackup{
+		ID:          "b1",
+		AgentID:     "agent1",
+		StartTime:   completed.Add(-10 * time.Minute),
+		CompletedAt: &completed,
+		Success:     true,
+	})
+	e.runOnce(t)
+
+	if !e.closedInSlide("a1") {
+		t.Error("alert was not closed in Slide after a successful backup")
+	}
+	ticket = e.ticketFor(t, "a1")
+	if !ticket.ClosedFlag || ticket.Status.Name != "Done" {
+		t.Errorf("ticket status = %q (closed %v), want Done", ticket.Status.Name, ticket.ClosedFlag)
+	}
+	if !e.hasNote(ticket.ID, "Successful backup b1") {
+		t.Error("closing note does not cite the backup")
+	}
+
+	mapping, err := e.db.GetAlertTicketMapping(e.ctx, "a1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapping.ClosedAt == nil {
+		t.Error("mapping still open after the alert resolved")
+	}
+	if !strings.Contains(mapping.Resolution, "b1") {
+		t.Errorf("resolution = %q, want the backup as evidence", mapping.Resolution)
+	}
+}
+
+func TestRunOnceClosesAlertWhenTicketClosed(t *testing.T) {
+	e := newTestEnv(t)
+	e.slide.AddAlerts(testAlert("a1", "agent1", "backup_failed", time.Now().Add(-time.Hour)))
+	e.runOnce(t)
+
+	// A technician closes the ticket in ConnectWise
+	ticketID := e.ticketFor(t, "a1").ID
+	if err := e.cw.SetTicketStatus(ticketID, "Done"); err != nil {
+		t.Fatal(err)
+	}
+	e.runOnce(t)
+
+	if !e.closedInSlide("a1") {
+		t.Error("alert was not closed in Slide after its ticket closed")
+	}
+	if alert, _ := e.slide.Alert("a1"); !alert.Resolved {
+		t.Error("alert still unresolved in Slide")
+	}
+	mapping, err := e.db.GetAlertTicketMapping(e.ctx, "a1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapping.ClosedAt == nil {
+		t.Error("mapping still open after the ticket closed")
+	}
+
+	// The closed alert is not ticketed again
+	e.runOnce(t)
+	if n := len(e.cw.Tickets()); n != 1 {
+		t.Errorf("got %d tickets, want 1", n)
+	}
+}
+
+func TestHandleTicketUpdateWaitsForAlertHandling(t *testing.T) {
+	e := newTestEnv(t)
+	e.slide.AddAlerts(testAlert("a1", "agent1", "backup_failed", time.Now().Add(-time.Hour)))
+	e.runOnce(t)
+	ticketID := closeTicketAsTech(t, e, "a1")
+
+	// While a webhook or the polling loop is handling alerts, the callback waits its turn
+	e.mon.alertMu.Lock()
+	done := make(chan error, 1)
+	go func() { done <- e.mon.HandleTicketUpdate(e.ctx, ticketID) }()
+
+	select {
+	case err := <-done:
+		e.mon.alertMu.Unlock()
+		t.Fatalf("HandleTicketUpdate returned (err %v) while alert handling was in progress", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	e.mon.alertMu.Unlock()
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if !e.closedInSlide("a1") {
+		t.Error("alert not closed once the callback could run")
+	}
+}
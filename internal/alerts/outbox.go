@@ -0,0 +1,108 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"slide-cw-integration/pkg/models"
+)
+
+// pendingTicketSlack widens the ConnectWise search window to allow for clock
+// differences between this host and ConnectWise
+const pendingTicketSlack = 10 * time.Minute
+
+// alertTag is appended to every ticket description so the ticket can be traced back to its alert
+func alertTag(alertID string) string {
+	return fmt.Sprintf("[slide-alert:%s]", alertID)
+}
+
+// ReconcilePendingTickets resolves ticket creations that were interrupted, e.g. by a crash
+// between ConnectWise creating the ticket and the mapping being saved. Tickets found in
+// ConnectWise are adopted; markers with no ticket are cleared so the alert is ticketed normally.
+func (m *Monitor) ReconcilePendingTickets(ctx context.Context) error {
+	pending, err := m.db.GetPendingTickets(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get pending tickets: %w", err)
+	}
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	log.Printf("Reconciling %d interrupted ticket creations", len(pending))
+	for _, p := range pending {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		m.alertMu.Lock()
+		_, err := m.adoptPendingTicket(ctx, p.AlertID)
+		m.alertMu.Unlock()
+
+		if err != nil {
+			log.Printf("Warning: could not reconcile pending ticket for alert %s, will try again: %v", p.AlertID, err)
+		}
+	}
+
+	return nil
+}
+
+// adoptPendingTicket looks for a ticket created by an interrupted attempt for alertID.
+// It reports true if the alert now has a ticket mapping. Without a pending marker it does nothing.
+func (m *Monitor) adoptPendingTicket(ctx context.Context, alertID string) (bool, error) {
+	pending, err := m.db.GetPendingTicket(ctx, alertID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check pending ticket for alert %s: %w", alertID, err)
+	}
+	if pending == nil {
+		return false, nil
+	}
+
+	// The mapping may have been saved with only the marker cleanup lost
+	existing, err := m.mappingService.GetAlertTicketMapping(ctx, alertID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check existing ticket mapping: %w", err)
+	}
+	if existing != nil {
+		m.clearPendingTicket(ctx, alertID)
+		return true, nil
+	}
+
+	since := pending.CreatedAt.Add(-pendingTicketSlack)
+	ticket, err := m.connectWise.FindTicketByNote(ctx, pending.CompanyID, since, alertTag(alertID))
+	if err != nil {
+		return false, fmt.Errorf("failed to search ConnectWise for an earlier ticket for alert %s: %w", alertID, err)
+	}
+
+	if ticket == nil {
+		log.Printf("No ConnectWise ticket found from the interrupted attempt for alert %s", alertID)
+		m.clearPendingTicket(ctx, alertID)
+		return false, nil
+	}
+
+	// The mapping wants the alert's agent name and when it was raised, so fetch it again.
+	// Only an alert Slide no longer has falls back to what the marker recorded.
+	alert, err := m.slideClient.GetAlert(ctx, alertID)
+	if err != nil {
+		if !isNotFound(err) {
+			return false, fmt.Errorf("failed to get alert %s for adopted ticket %d: %w", alertID, ticket.ID, err)
+		}
+		alert = &models.SlideAlert{ID: alertID, AgentID: pending.AgentID, Type: pending.AlertType}
+	}
+	if err := m.mappingService.SaveAlertTicketMapping(ctx, alert, ticket.ID, pending.ClientID, pending.CorrelationKey); err != nil {
+		return false, fmt.Errorf("failed to save alert-ticket mapping for adopted ticket %d: %w", ticket.ID, err)
+	}
+	m.clearPendingTicket(ctx, alertID)
+
+	log.Printf("Adopted ConnectWise ticket %d for alert %s from an interrupted attempt", ticket.ID, alertID)
+	return true, nil
+}
+
+// clearPendingTicket removes an alert's pending marker, logging rather than failing
+func (m *Monitor) clearPendingTicket(ctx context.Context, alertID string) {
+	if err := m.db.DeletePendingTicket(ctx, alertID); err != nil {
+		log.Printf("Warning: failed to clear pending ticket for alert %s: %v", alertID, err)
+	}
+}
@@ -0,0 +1,108 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"slide-cw-integration/pkg/models"
+)
+
+// interruptCreation leaves things as a crash between ConnectWise creating alertID's ticket
+// and the mapping being saved would: a pending marker and an unmapped, tagged ticket.
+// Without withTicket the crash came before ConnectWise created anything.
+func interruptCreation(t *testing.T, e *testEnv, alertID string, withTicket bool) int {
+	t.Helper()
+
+	if err := e.db.SavePendingTicket(e.ctx, &models.PendingTicket{
+		AlertID:   alertID,
+		CompanyID: 5,
+		ClientID:  "c1",
+		AgentID:   "agent1",
+		AlertType: "backup_failed",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !withTicket {
+		return 0
+	}
+
+	config, err := e.db.GetTicketingConfig(e.ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticket, err := e.cw.Client().CreateTicketWithConfig(e.ctx, 5, "backup_failed on agent1",
+		"Backup of agent1 failed\n\n"+alertTag(alertID), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ticket.ID
+}
+
+func TestReconcileAdoptsInterruptedTicket(t *testing.T) {
+	e := newTestEnv(t)
+	e.slide.AddAlerts(testAlert("a1", "agent1", "backup_failed", time.Now().Add(-time.Hour)))
+	ticketID := interruptCreation(t, e, "a1", true)
+
+	if err := e.mon.ReconcilePendingTickets(e.ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := e.ticketFor(t, "a1").ID; got != ticketID {
+		t.Errorf("alert mapped to ticket %d, want the interrupted ticket %d", got, ticketID)
+	}
+	if pending, _ := e.db.GetPendingTicket(e.ctx, "a1"); pending != nil {
+		t.Error("pending marker left after adopting the ticket")
+	}
+
+	// The adopted mapping is as complete as one saved when the ticket was created
+	mapping, err := e.db.GetAlertTicketMapping(e.ctx, "a1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mapping.ClientID != "c1" || mapping.AgentName != "agent1" || mapping.AlertCreatedAt == nil {
+		t.Errorf("adopted mapping has client %q, agent name %q and raised time %v; want c1, agent1 and the alert's time",
+			mapping.ClientID, mapping.AgentName, mapping.AlertCreatedAt)
+	}
+
+	e.runOnce(t)
+	if n := len(e.cw.Tickets()); n != 1 {
+		t.Errorf("got %d tickets, want just the adopted one", n)
+	}
+}
+
+func TestRunOnceAdoptsInterruptedTicketInsteadOfDuplicating(t *testing.T) {
+	e := newTestEnv(t)
+	e.slide.AddAlerts(testAlert("a1", "agent1", "backup_failed", time.Now().Add(-time.Hour)))
+	ticketID := interruptCreation(t, e, "a1", true)
+
+	e.runOnce(t)
+
+	if n := len(e.cw.Tickets()); n != 1 {
+		t.Fatalf("got %d tickets, want just the interrupted one", n)
+	}
+	if got := e.ticketFor(t, "a1").ID; got != ticketID {
+		t.Errorf("alert mapped to ticket %d, want the interrupted ticket %d", got, ticketID)
+	}
+}
+
+func TestReconcileClearsMarkerWithoutTicket(t *testing.T) {
+	e := newTestEnv(t)
+	e.slide.AddAlerts(testAlert("a1", "agent1", "backup_failed", time.Now().Add(-time.Hour)))
+	interruptCreation(t, e, "a1", false)
+
+	if err := e.mon.ReconcilePendingTickets(e.ctx); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := e.db.GetPendingTicket(e.ctx, "a1"); pending != nil {
+		t.Fatal("pending marker left although ConnectWise has no ticket for it")
+	}
+	if mapping, _ := e.db.GetAlertTicketMapping(e.ctx, "a1"); mapping != nil {
+		t.Fatalf("alert mapped to ticket %d that was never created", mapping.TicketID)
+	}
+
+	// The alert is then ticketed as normal
+	e.runOnce(t)
+	if n := len(e.cw.Tickets()); n != 1 {
+		t.Errorf("got %d tickets, want 1", n)
+	}
+}
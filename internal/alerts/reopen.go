@@ -0,0 +1,124 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"slide-cw-integration/internal/connectwise"
+	"slide-cw-integration/pkg/models"
+)
+
+// defaultReopenWindow applies when the configured window is unset
+const defaultReopenWindow = 7 * 24 * time.Hour
+
+// reopenWindow is how recently an agent's ticket must have closed for a recurring alert to reuse or reference it
+func reopenWindow(config *models.TicketingConfig) time.Duration {
+	if config.ReopenWindowDays <= 0 {
+		return defaultReopenWindow
+	}
+	return time.Duration(config.ReopenWindowDays) * 24 * time.Hour
+}
+
+// recentlyClosedTicket returns the mapping of the agent's last ticket for alert's type if it
+// closed within the reopen window, or nil when there is none or the reopen policy is off
+func (m *Monitor) recentlyClosedTicket(ctx context.Context, alert *models.SlideAlert, config *models.TicketingConfig) (*models.AlertTicketMapping, error) {
+	if config.ReopenMode != models.ReopenTicket && config.ReopenMode != models.ReopenLink {
+		return nil, nil
+	}
+	if alert.AgentID == "" {
+		return nil, nil
+	}
+
+	previous, err := m.db.GetLastClosedAlertTicketMapping(ctx, alert.AgentID, alert.Type)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up closed tickets for agent %s: %w", alert.AgentID, err)
+	}
+	if previous == nil || previous.ClosedAt == nil || previous.ClosedAt.Before(time.Now().Add(-reopenWindow(config))) {
+		return nil, nil
+	}
+	return previous, nil
+}
+
+// reopenTicket moves previous's ticket back to an open status and tracks alert on it,
+// noting why on the ticket. It reports false if the ticket no longer exists.
+func (m *Monitor) reopenTicket(ctx context.Context, alert *models.SlideAlert, req *ticketRequest, previous *models.AlertTicketMapping) (bool, error) {
+	ticket, err := m.connectWise.GetTicket(ctx, previous.TicketID)
+	if err != nil {
+		if isNotFound(err) {
+			log.Printf("Warning: closed ticket %d for agent %s is gone, opening a new one", previous.TicketID, alert.AgentID)
+			return false, nil
+		}
+		return false, err
+	}
+
+	// A technician may have reopened it already
+	if ticket.IsClosed() {
+		status, err := m.reopenStatus(ctx, ticket, req.config)
+		if err != nil {
+			return false, err
+		}
+		if err := m.connectWise.UpdateTicket(ctx, ticket.ID, status); err != nil {
+			return false, fmt.Errorf("failed to reopen ticket %d: %w", ticket.ID, err)
+		}
+	}
+
+	if err := m.mappingService.SaveAlertTicketMapping(ctx, alert, ticket.ID, req.clientID, req.correlationKey); err != nil {
+		return false, fmt.Errorf("reopened ticket %d but failed to save alert-ticket mapping for alert %s: %w", ticket.ID, alert.ID, err)
+	}
+
+	alertMessage := alert.GetParsedMessage()
+	if alertMessage == "" {
+		alertMessage = alert.Message
+	}
+
+	m.addTicketNote(ctx, ticket.ID, connectwise.NoteInternal, fmt.Sprintf(
+		"Reopened: agent %s raised the same Slide alert again after this ticket closed on %s.\n\nAlert: %s\nType: %s\nMessage: %s\nRaised: %s",
+		req.agentName, previous.ClosedAt.UTC().Format(noteTimeFormat), alert.ID, alert.Type, alertMessage,
+		alert.Timestamp.UTC().Format(noteTimeFormat)))
+
+	log.Printf("Reopened ConnectWise ticket %d for recurring alert %s", ticket.ID, alert.ID)
+	return true, nil
+}
+
+// reopenStatus is the status a reopened ticket moves to: the configured status for new
+// tickets when it is on that board, otherwise the first open status on its own board
+func (m *Monitor) reopenStatus(ctx context.Context, ticket *models.ConnectWiseTicket, config *models.TicketingConfig) (string, error) {
+	if ticket.Board.ID == config.BoardID && config.StatusName != "" {
+		return config.StatusName, nil
+	}
+
+	statuses, err := m.connectWise.GetStatuses(ctx, ticket.Board.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to find an open status to reopen ticket %d with: %w", ticket.ID, err)
+	}
+
+	sort.SliceStable(statuses, func(i, j int) bool { return statuses[i].SortOrder < statuses[j].SortOrder })
+	for _, status := range statuses {
+		if !status.ClosedStatus && !status.Inactive {
+			return status.Name, nil
+		}
+	}
+	return "", fmt.Errorf("board %d has no open status to reopen ticket %d with", ticket.Board.ID, ticket.ID)
+}
+
+// openStatus is reopenStatus under the current ticketing config
+func (m *Monitor) openStatus(ctx context.Context, ticket *models.ConnectWiseTicket) (string, error) {
+	config, err := m.db.GetTicketingConfig(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get ticketing config: %w", err)
+	}
+	if config == nil {
+		config = &models.TicketingConfig{}
+	}
+	return m.reopenStatus(ctx, ticket, config)
+}
+
+// previousTicketReference is appended to a new ticket's description when it links back
+// to the agent's recently closed ticket for the same alert type
+func previousTicketReference(previous *models.AlertTicketMapping) string {
+	return fmt.Sprintf("\n\nRecurring alert: this agent's previous ticket for the same alert type, #%d, closed on %s.",
+		previous.TicketID, previous.ClosedAt.UTC().Format(noteTimeFormat))
+}
@@ -0,0 +1,262 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"slide-cw-integration/internal/database"
+	"slide-cw-integration/internal/slide"
+	"slide-cw-integration/pkg/models"
+)
+
+// defaultStorageResolvePercent applies when the configured storage threshold is unset
+const defaultStorageResolvePercent = 80
+
+// Resolver decides whether the problem behind a Slide alert has cleared. It returns the
+// evidence it relied on, which is cited in the ticket's closing note, or nil while the
+// problem persists.
+type Resolver interface {
+	Resolve(ctx context.Context, alert *models.SlideAlert) (*models.AlertResolution, error)
+}
+
+// ResolverFunc adapts an ordinary function to a Resolver
+type ResolverFunc func(ctx context.Context, alert *models.SlideAlert) (*models.AlertResolution, error)
+
+func (f ResolverFunc) Resolve(ctx context.Context, alert *models.SlideAlert) (*models.AlertResolution, error) {
+	return f(ctx, alert)
+}
+
+// RegisterResolver makes r decide when alerts of the given types have resolved, replacing
+// any resolver already registered for them. Call it before Start.
+func (m *Monitor) RegisterResolver(r Resolver, alertTypes ...string) {
+	if m.resolvers == nil {
+		m.resolvers = make(map[string]Resolver)
+	}
+	for _, alertType := range alertTypes {
+		m.resolvers[strings.ToLower(alertType)] = r
+	}
+}
+
+// registerDefaultResolvers sets up the built-in resolvers for the alert types Slide raises
+func (m *Monitor) registerDefaultResolvers() {
+	m.RegisterResolver(&backupResolver{slide: m.slideClient, db: m.db},
+		"backup_failed", "backup_error", "agent_backup_failed")
+	m.RegisterResolver(&agentCheckInResolver{slide: m.slideClient},
+		"agent_not_checking_in", "agent_offline")
+	m.RegisterResolver(&storageResolver{slide: m.slideClient, db: m.db},
+		"device_storage_space_low", "device_storage_space_critical", "storage_low")
+	m.RegisterResolver(&replicationResolver{slide: m.slideClient},
+		"replication_failed", "device_replication_failed", "cloud_replication_failed")
+}
+
+// resolverFor returns the resolver registered for alertType, or nil if there is none
+func (m *Monitor) resolverFor(alertType string) Resolver {
+	return m.resolvers[strings.ToLower(alertType)]
+}
+
+// backupResolver clears backup alerts once the agent completes a successful backup, or
+// as many consecutive successful backups as the alert type's policy asks for
+type backupResolver struct {
+	slide SlideAPI
+	db    *database.DB
+}
+
+func (r *backupResolver) Resolve(ctx context.Context, alert *models.SlideAlert) (*models.AlertResolution, error) {
+	if alert.AgentID == "" {
+		return nil, nil
+	}
+
+	backups, err := r.slide.ListBackups(ctx, slide.BackupListOptions{AgentID: alert.AgentID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backups for agent %s: %w", alert.AgentID, err)
+	}
+
+	policy, err := r.db.GetAlertTypePolicy(ctx, alert.Type)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policy for alert type %s: %w", alert.Type, err)
+	}
+	if policy != nil && policy.VerifiesBackups() {
+		return verifyBackups(alert, backups, policy), nil
+	}
+
+	for _, backup := range backups {
+		if backup.AgentID == alert.AgentID &&
+			backup.Success &&
+			backup.CompletedAt != nil &&
+			backup.CompletedAt.After(alert.Timestamp) {
+			return &models.AlertResolution{
+				Resolver: "backup",
+				Evidence: fmt.Sprintf("Successful backup %s completed at %s.",
+					backup.ID, backup.CompletedAt.UTC().Format(noteTimeFormat)),
+				ObservedAt: *backup.CompletedAt,
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// verifyBackups checks the agent's most recent run of successful backups since alert was
+// raised against policy. It returns nil when the latest backup did not succeed, and a
+// pending resolution while the run is too short.
+func verifyBackups(alert *models.SlideAlert, backups []models.SlideBackup, policy *models.AlertTypePolicy) *models.AlertResolution {
+	var completed []models.SlideBackup
+	for _, backup := range backups {
+		if backup.AgentID == alert.AgentID && backup.CompletedAt != nil && backup.CompletedAt.After(alert.Timestamp) {
+			completed = append(completed, backup)
+		}
+	}
+	sort.SliceStable(completed, func(i, j int) bool { return completed[i].CompletedAt.Before(*completed[j].CompletedAt) })
+
+	start := len(completed)
+	for start > 0 && completed[start-1].Success {
+		start--
+	}
+	run := completed[start:]
+	if len(run) == 0 {
+		return nil
+	}
+
+	required := policy.VerifyBackups
+	if required < 1 {
+		required = 1
+	}
+	first, last := run[0], run[len(run)-1]
+	span := last.CompletedAt.Sub(*first.CompletedAt)
+
+	if len(run) < required || span < time.Duration(policy.VerifyHours)*time.Hour {
+		spanning := ""
+		if policy.VerifyHours > 0 {
+			spanning = fmt.Sprintf(", spanning %.1f of the required %d hours", span.Hours(), policy.VerifyHours)
+		}
+		return &models.AlertResolution{
+			Resolver: "backup",
+			Evidence: fmt.Sprintf("Backup %s succeeded at %s: %d of %d consecutive successful backups%s.",
+				last.ID, last.CompletedAt.UTC().Format(noteTimeFormat), len(run), required, spanning),
+			ObservedAt: *last.CompletedAt,
+			Pending:    true,
+		}
+	}
+
+	return &models.AlertResolution{
+		Resolver: "backup",
+		Evidence: fmt.Sprintf("%d consecutive successful backups completed between %s and %s (latest %s).",
+			len(run), first.CompletedAt.UTC().Format(noteTimeFormat), last.CompletedAt.UTC().Format(noteTimeFormat), last.ID),
+		ObservedAt: *last.CompletedAt,
+	}
+}
+
+// agentCheckInResolver clears offline alerts once the agent checks in again
+type agentCheckInResolver struct {
+	slide SlideAPI
+}
+
+func (r *agentCheckInResolver) Resolve(ctx context.Context, alert *models.SlideAlert) (*models.AlertResolution, error) {
+	if alert.AgentID == "" {
+		return nil, nil
+	}
+
+	agent, err := r.slide.GetAgent(ctx, alert.AgentID)
+	if err != nil {
+		return nil, err
+	}
+	if agent.LastSeenAt == nil || !agent.LastSeenAt.After(alert.Timestamp) {
+		return nil, nil
+	}
+
+	name := agent.DisplayName
+	if name == "" {
+		name = agent.ID
+	}
+	return &models.AlertResolution{
+		Resolver:   "agent_check_in",
+		Evidence:   fmt.Sprintf("Agent %s checked in at %s.", name, agent.LastSeenAt.UTC().Format(noteTimeFormat)),
+		ObservedAt: *agent.LastSeenAt,
+	}, nil
+}
+
+// storageResolver clears device storage alerts once usage drops below the configured threshold
+type storageResolver struct {
+	slide SlideAPI
+	db    *database.DB
+}
+
+func (r *storageResolver) Resolve(ctx context.Context, alert *models.SlideAlert) (*models.AlertResolution, error) {
+	if alert.DeviceID == "" {
+		return nil, nil
+	}
+
+	threshold := float64(defaultStorageResolvePercent)
+	config, err := r.db.GetTicketingConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticketing config: %w", err)
+	}
+	if config != nil && config.StorageResolvePercent > 0 {
+		threshold = float64(config.StorageResolvePercent)
+	}
+
+	device, err := r.slide.GetDevice(ctx, alert.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	used := device.StorageUsedPercent()
+	if used < 0 || used >= threshold {
+		return nil, nil
+	}
+
+	return &models.AlertResolution{
+		Resolver: "storage",
+		Evidence: fmt.Sprintf("Device %s storage is %.1f%% used (%s of %s), below the %.0f%% threshold.",
+			device.Name, used, formatBytes(device.StorageUsedBytes), formatBytes(device.StorageTotalBytes), threshold),
+		ObservedAt: time.Now(),
+	}, nil
+}
+
+// replicationResolver clears replication alerts once a later replication succeeds
+type replicationResolver struct {
+	slide SlideAPI
+}
+
+func (r *replicationResolver) Resolve(ctx context.Context, alert *models.SlideAlert) (*models.AlertResolution, error) {
+	if alert.DeviceID == "" {
+		return nil, nil
+	}
+
+	replications, err := r.slide.ListReplications(ctx, slide.ReplicationListOptions{
+		DeviceID: alert.DeviceID,
+		AgentID:  alert.AgentID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, replication := range replications {
+		if replication.Success &&
+			replication.CompletedAt != nil &&
+			replication.CompletedAt.After(alert.Timestamp) {
+			return &models.AlertResolution{
+				Resolver: "replication",
+				Evidence: fmt.Sprintf("Successful replication %s completed at %s.",
+					replication.ID, replication.CompletedAt.UTC().Format(noteTimeFormat)),
+				ObservedAt: *replication.CompletedAt,
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// formatBytes renders a byte count in binary units for ticket notes
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
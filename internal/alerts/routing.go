@@ -0,0 +1,24 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"slide-cw-integration/pkg/models"
+)
+
+// matchRoutingRule returns the first enabled routing rule matching facts, or nil if the
+// alert should be ticketed with the ticketing configuration as it is
+func (m *Monitor) matchRoutingRule(ctx context.Context, facts models.RoutingFacts) (*models.RoutingRule, error) {
+	rules, err := m.db.GetRoutingRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load routing rules: %w", err)
+	}
+
+	rule := models.MatchRoutingRule(rules, facts)
+	if rule != nil {
+		log.Printf("Alert type %s for agent %s matched routing rule %q (%s)", facts.AlertType, facts.AgentName, rule.Name, rule.Action)
+	}
+	return rule, nil
+}
@@ -0,0 +1,128 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"slide-cw-integration/internal/connectwise"
+	"slide-cw-integration/pkg/models"
+)
+
+// stormKeyPrefix starts the correlation key of alerts rolled up onto a client's alert storm ticket
+const stormKeyPrefix = "storm:"
+
+// stormCounter counts the tickets opened since the current cycle began, per Slide client and in total
+type stormCounter struct {
+	mu        sync.Mutex
+	perClient map[string]int
+	total     int
+}
+
+func (c *stormCounter) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.perClient = nil
+	c.total = 0
+}
+
+func (c *stormCounter) counts(clientID string) (client, total int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.perClient[clientID], c.total
+}
+
+func (c *stormCounter) record(clientID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.perClient == nil {
+		c.perClient = make(map[string]int)
+	}
+	c.perClient[clientID]++
+	c.total++
+}
+
+// applyStormProtection runs before a new ticket is opened for alert. It reports true if
+// the alert was dealt with another way: added to its client's open storm ticket, or held
+// because the circuit breaker has tripped. Otherwise, if the client has reached its cap
+// this cycle, req is turned into the client's storm ticket.
+func (m *Monitor) applyStormProtection(ctx context.Context, alert *models.SlideAlert, req *ticketRequest) (bool, error) {
+	key := stormKeyPrefix + req.clientID
+	storm, err := m.db.GetOpenAlertTicketMappingsByCorrelationKey(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up alert storm ticket for client %s: %w", req.clientID, err)
+	}
+	if len(storm) > 0 {
+		return true, m.joinStorm(ctx, alert, req, storm)
+	}
+
+	trippedAt, err := m.db.GetCircuitBreakerTrippedAt(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to check the circuit breaker: %v", err)
+	}
+	if trippedAt != nil {
+		log.Printf("Holding alert %s: the circuit breaker tripped at %s and ticket creation is paused until it is reset",
+			alert.ID, trippedAt.Local().Format(noteTimeFormat))
+		return true, nil
+	}
+
+	clientTickets, totalTickets := m.storm.counts(req.clientID)
+	if limit := req.config.StormTotalLimit; limit > 0 && totalTickets >= limit {
+		if err := m.db.TripCircuitBreaker(ctx, time.Now()); err != nil {
+			return false, fmt.Errorf("failed to trip the circuit breaker: %w", err)
+		}
+		log.Printf("Circuit breaker tripped: %d tickets opened this cycle (limit %d). Ticket creation is paused until it is reset from the dashboard",
+			totalTickets, limit)
+		return true, nil
+	}
+
+	if limit := req.config.StormClientLimit; limit > 0 && clientTickets >= limit {
+		log.Printf("Client %s has had %d tickets this cycle; opening an alert storm ticket for alert %s", req.clientName, clientTickets, alert.ID)
+		req.summary = fmt.Sprintf("Alert storm: %s", req.clientName)
+		req.description = fmt.Sprintf(
+			"%s raised more Slide alerts in one check than the limit of %d tickets per client, so the rest are tracked together on this ticket. New and resolved alerts are noted here as they happen, and the ticket closes once all of them have resolved.\n\nAffected agents:\n%s\n\n%s",
+			req.clientName, limit, stormRosterLine(req.agentName, alert.Type, alert.ID), alertTag(alert.ID))
+		req.correlationKey = key
+	}
+	return false, nil
+}
+
+// joinStorm adds alert to the client's open storm ticket and notes the agents now affected
+func (m *Monitor) joinStorm(ctx context.Context, alert *models.SlideAlert, req *ticketRequest, storm []models.AlertTicketMapping) error {
+	ticketID := storm[0].TicketID
+	if err := m.mappingService.SaveAlertTicketMapping(ctx, alert, ticketID, req.clientID, stormKeyPrefix+req.clientID); err != nil {
+		return fmt.Errorf("failed to add alert %s to alert storm ticket %d: %w", alert.ID, ticketID, err)
+	}
+
+	m.addTicketNote(ctx, ticketID, connectwise.NoteInternal, fmt.Sprintf(
+		"Alert storm: agent %s raised %s (%s).\n\nAffected agents (%d):\n%s",
+		req.agentName, alert.Type, alert.ID, len(storm)+1,
+		stormRoster(storm)+"\n"+stormRosterLine(req.agentName, alert.Type, alert.ID)))
+
+	log.Printf("Added alert %s to alert storm ticket %d for client %s", alert.ID, ticketID, req.clientName)
+	return nil
+}
+
+// stormRoster lists the agents and alerts on a storm ticket, one per line, oldest first
+func stormRoster(mappings []models.AlertTicketMapping) string {
+	sorted := append([]models.AlertTicketMapping(nil), mappings...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	lines := make([]string, 0, len(sorted))
+	for _, mapping := range sorted {
+		agent := mapping.AgentName
+		if agent == "" {
+			agent = mapping.AgentID
+		}
+		lines = append(lines, stormRosterLine(agent, mapping.AlertType, mapping.AlertID))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func stormRosterLine(agentName, alertType, alertID string) string {
+	return fmt.Sprintf("- %s: %s (%s)", agentName, alertType, alertID)
+}
@@ -0,0 +1,83 @@
+package alerts
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"slide-cw-integration/pkg/models"
+)
+
+// addAgentAlerts raises one backup alert for each of n agents, a minute apart
+func addAgentAlerts(e *testEnv, n int) {
+	start := time.Now().Add(-time.Hour)
+	for i := 1; i <= n; i++ {
+		id := strconv.Itoa(i)
+		e.slide.AddAlerts(testAlert("a"+id, "agent"+id, "backup_failed", start.Add(time.Duration(i)*time.Minute)))
+	}
+}
+
+func TestStormRollsUpClientAlerts(t *testing.T) {
+	e := newTestEnv(t)
+	e.configure(t, func(config *models.TicketingConfig) { config.StormClientLimit = 1 })
+	addAgentAlerts(e, 3)
+
+	e.runOnce(t)
+
+	if n := len(e.cw.Tickets()); n != 2 {
+		t.Fatalf("got %d tickets, want one for the first alert and one storm ticket", n)
+	}
+	storm := e.ticketFor(t, "a2")
+	if storm.Summary != "Alert storm: Acme Corp" {
+		t.Errorf("storm ticket summary = %q", storm.Summary)
+	}
+	if got := e.ticketFor(t, "a3").ID; got != storm.ID {
+		t.Errorf("alert a3 on ticket %d, want the storm ticket %d", got, storm.ID)
+	}
+	if !e.hasNote(storm.ID, "Affected agents (2)") {
+		t.Error("storm ticket has no roster note for the alert that joined it")
+	}
+
+	// The storm ticket stays open until its last alert resolves
+	for _, alertID := range []string{"a2", "a3"} {
+		if err := e.slide.Client().CloseAlert(e.ctx, alertID); err != nil {
+			t.Fatal(err)
+		}
+		e.runOnce(t)
+
+		closed := e.ticketFor(t, "a2").ClosedFlag
+		if want := alertID == "a3"; closed != want {
+			t.Fatalf("after %s resolved, storm ticket closed = %v, want %v", alertID, closed, want)
+		}
+	}
+}
+
+func TestCircuitBreakerPausesTicketing(t *testing.T) {
+	e := newTestEnv(t)
+	e.configure(t, func(config *models.TicketingConfig) { config.StormTotalLimit = 2 })
+	addAgentAlerts(e, 3)
+
+	e.runOnce(t)
+
+	if n := len(e.cw.Tickets()); n != 2 {
+		t.Fatalf("got %d tickets, want the limit of 2", n)
+	}
+	trippedAt, err := e.db.GetCircuitBreakerTrippedAt(e.ctx)
+	if err != nil || trippedAt == nil {
+		t.Fatalf("circuit breaker did not trip (err %v)", err)
+	}
+
+	// Tripped, it holds new tickets across cycles until it is reset
+	e.runOnce(t)
+	if n := len(e.cw.Tickets()); n != 2 {
+		t.Fatalf("got %d tickets with the circuit breaker tripped, want 2", n)
+	}
+
+	if err := e.db.ResetCircuitBreaker(e.ctx); err != nil {
+		t.Fatal(err)
+	}
+	e.runOnce(t)
+	if n := len(e.cw.Tickets()); n != 3 {
+		t.Errorf("got %d tickets after resetting the circuit breaker, want 3", n)
+	}
+}
@@ -0,0 +1,118 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"slide-cw-integration/internal/connectwise"
+	"slide-cw-integration/internal/slide"
+	"slide-cw-integration/pkg/models"
+)
+
+// syncPolicy returns the sync policy for a Slide client: its mapping's override, else the
+// global setting, else two-way. clientID is the client resolved from the alert's device,
+// as saved on its ticket mapping, not the alert's account ID.
+func (m *Monitor) syncPolicy(ctx context.Context, clientID string) models.SyncPolicy {
+	if clientID != "" {
+		mapping, err := m.mappingService.GetClientMapping(ctx, clientID)
+		if err != nil {
+			log.Printf("Warning: failed to load sync policy for client %s: %v", clientID, err)
+		} else if mapping != nil && mapping.SyncPolicy.Valid() {
+			return mapping.SyncPolicy
+		}
+	}
+
+	config, err := m.db.GetTicketingConfig(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to load global sync policy: %v", err)
+	} else if config != nil && config.SyncPolicy.Valid() {
+		return config.SyncPolicy
+	}
+	return models.SyncTwoWay
+}
+
+// closureBlocked explains why the sync policy keeps mapping's Slide alert open now its
+// ticket has closed in ConnectWise, or returns "" if the alert may be closed
+func (m *Monitor) closureBlocked(ctx context.Context, mapping models.AlertTicketMapping) (string, error) {
+	policy := m.syncPolicy(ctx, mapping.ClientID)
+	if policy != models.SyncSlideAuthoritative && policy != models.SyncRequireBackup {
+		return "", nil
+	}
+
+	alert, err := m.slideClient.GetAlert(ctx, mapping.AlertID)
+	if err != nil {
+		if isNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	if alert.Resolved {
+		return "", nil
+	}
+
+	if policy == models.SyncSlideAuthoritative {
+		return fmt.Sprintf("Slide alert %s (%s) is still open, and only Slide may close it", alert.ID, alert.Type), nil
+	}
+
+	ok, err := m.hasSuccessfulBackup(ctx, alert)
+	if err != nil || ok {
+		return "", err
+	}
+	return fmt.Sprintf("agent %s has not had a successful backup since Slide alert %s (%s) was raised",
+		mapping.AgentName, alert.ID, alert.Type), nil
+}
+
+// hasSuccessfulBackup reports whether alert's agent has completed a successful backup since
+// the alert was raised. Alerts not tied to an agent have nothing to wait for.
+func (m *Monitor) hasSuccessfulBackup(ctx context.Context, alert *models.SlideAlert) (bool, error) {
+	if alert.AgentID == "" {
+		return true, nil
+	}
+
+	backups, err := m.slideClient.ListBackups(ctx, slide.BackupListOptions{AgentID: alert.AgentID})
+	if err != nil {
+		return false, fmt.Errorf("failed to get backups for agent %s: %w", alert.AgentID, err)
+	}
+	for _, backup := range backups {
+		if backup.AgentID == alert.AgentID && backup.Success && backup.CompletedAt != nil && backup.CompletedAt.After(alert.Timestamp) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// reopenBlockedTicket reopens a ticket that was closed in ConnectWise against the sync
+// policy and notes why its Slide alerts stay open
+func (m *Monitor) reopenBlockedTicket(ctx context.Context, ticket *models.ConnectWiseTicket, reasons []string) {
+	note := "Closing this ticket did not close its Slide alerts because of the sync policy:\n- " +
+		strings.Join(reasons, "\n- ")
+
+	status, err := m.openStatus(ctx, ticket)
+	if err == nil {
+		err = m.connectWise.UpdateTicket(ctx, ticket.ID, status)
+	}
+	if err != nil {
+		log.Printf("Warning: failed to reopen ticket %d closed against the sync policy: %v", ticket.ID, err)
+	} else {
+		note += fmt.Sprintf("\n\nReopened to %s.", status)
+	}
+
+	m.addTicketNote(ctx, ticket.ID, connectwise.NoteInternal, note)
+	log.Printf("Ticket %d was closed against the sync policy; reopened with a note", ticket.ID)
+}
+
+// noteResolvedInSlide records on a ticket how its alert was resolved while the
+// ConnectWise-authoritative sync policy leaves the ticket for a technician to close
+func (m *Monitor) noteResolvedInSlide(ctx context.Context, alert *models.SlideAlert, mapping *models.AlertTicketMapping, how, resolution string) {
+	if err := m.db.SetAlertResolution(ctx, alert.ID, resolution); err != nil {
+		log.Printf("Warning: failed to record resolution of alert %s: %v", alert.ID, err)
+	}
+
+	m.addTicketNote(ctx, mapping.TicketID, connectwise.NoteInternal, fmt.Sprintf(
+		"Slide alert %s (%s) was %s.\nEvidence: %s\nThe sync policy leaves closing this ticket to ConnectWise.",
+		alert.ID, alert.Type, how, resolution))
+
+	log.Printf("Alert %s resolved; leaving ticket %d open for ConnectWise to close", alert.ID, mapping.TicketID)
+}
@@ -0,0 +1,157 @@
+package alerts
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"slide-cw-integration/pkg/models"
+)
+
+// closeTicketAsTech closes alertID's ticket in ConnectWise as a technician would
+func closeTicketAsTech(t *testing.T, e *testEnv, alertID string) int {
+	t.Helper()
+
+	ticketID := e.ticketFor(t, alertID).ID
+	if err := e.cw.SetTicketStatus(ticketID, "Done"); err != nil {
+		t.Fatal(err)
+	}
+	return ticketID
+}
+
+func TestSlideAuthoritativeReopensClosedTicket(t *testing.T) {
+	e := newTestEnv(t)
+	e.configure(t, func(config *models.TicketingConfig) { config.SyncPolicy = models.SyncSlideAuthoritative })
+	e.slide.AddAlerts(testAlert("a1", "agent1", "backup_failed", time.Now().Add(-time.Hour)))
+	e.runOnce(t)
+
+	ticketID := closeTicketAsTech(t, e, "a1")
+	e.runOnce(t)
+
+	if e.closedInSlide("a1") {
+		t.Error("alert closed in Slide although only Slide may close it")
+	}
+	if ticket := e.ticketFor(t, "a1"); ticket.ClosedFlag || ticket.Status.Name != "New" {
+		t.Errorf("ticket status = %q (closed %v), want it reopened to New", ticket.Status.Name, ticket.ClosedFlag)
+	}
+	if !e.hasNote(ticketID, "only Slide may close it") {
+		t.Error("reopened ticket has no note explaining the sync policy")
+	}
+}
+
+func TestClientRequireBackupOverridesGlobalPolicy(t *testing.T) {
+	e := newTestEnv(t)
+	if err := e.db.SetClientSyncPolicy(e.ctx, "c1", models.SyncRequireBackup); err != nil {
+		t.Fatal(err)
+	}
+	// No resolver is registered for this type, so only the sync policy decides
+	e.slide.AddAlerts(testAlert("a1", "agent1", "custom_check_failed", time.Now().Add(-time.Hour)))
+	e.runOnce(t)
+
+	closeTicketAsTech(t, e, "a1")
+	e.runOnce(t)
+
+	if e.closedInSlide("a1") {
+		t.Fatal("alert closed before the agent had a successful backup")
+	}
+	if e.ticketFor(t, "a1").ClosedFlag {
+		t.Fatal("ticket left closed although the policy blocked closing its alert")
+	}
+
+	completed := time.Now()
+	e.slide.AddBackups(models.SlideBackup{ID: "b1", AgentID: "agent1", CompletedAt: &completed, Success: true})
+	closeTicketAsTech(t, e, "a1")
+	e.runOnce(t)
+
+	if !e.closedInSlide("a1") {
+		t.Error("alert not closed with its ticket after a successful backup")
+	}
+	if mapping, _ := e.db.GetAlertTicketMapping(e.ctx, "a1"); mapping == nil || mapping.ClosedAt == nil {
+		t.Error("mapping still open after the ticket and alert closed")
+	}
+}
+
+func TestConnectWiseAuthoritativeLeavesTicketOpen(t *testing.T) {
+	e := newTestEnv(t)
+	e.configure(t, func(config *models.TicketingConfig) { config.SyncPolicy = models.SyncConnectWiseAuthoritative })
+	e.slide.AddAlerts(testAlert("a1", "agent1", "custom_check_failed", time.Now().Add(-time.Hour)))
+	e.runOnce(t)
+
+	if err := e.slide.Client().CloseAlert(e.ctx, "a1"); err != nil {
+		t.Fatal(err)
+	}
+	e.runOnce(t)
+	e.runOnce(t)
+
+	ticket := e.ticketFor(t, "a1")
+	if ticket.ClosedFlag {
+		t.Fatal("ticket closed although only ConnectWise may close it")
+	}
+	notes := 0
+	for _, note := range e.cw.Notes(ticket.ID) {
+		if strings.Contains(note.Text, "leaves closing this ticket to ConnectWise") {
+			notes++
+		}
+	}
+	if notes != 1 {
+		t.Errorf("got %d notes that the alert resolved, want 1", notes)
+	}
+
+	closeTicketAsTech(t, e, "a1")
+	e.runOnce(t)
+	if mapping, _ := e.db.GetAlertTicketMapping(e.ctx, "a1"); mapping == nil || mapping.ClosedAt == nil {
+		t.Error("mapping still open after the technician closed the ticket")
+	}
+}
+
+// mspAlert is raised under an MSP's account, so only its device ties it to client c1
+func mspAlert(id, agentID, alertType string) models.SlideAlert {
+	alert := testAlert(id, agentID, alertType, time.Now().Add(-time.Hour))
+	alert.ClientID = "msp-account"
+	return alert
+}
+
+func TestClientSyncPolicyAppliesToMSPAccountAlerts(t *testing.T) {
+	e := newTestEnv(t)
+	if err := e.db.SetClientSyncPolicy(e.ctx, "c1", models.SyncRequireBackup); err != nil {
+		t.Fatal(err)
+	}
+	e.slide.AddAlerts(mspAlert("a1", "agent1", "custom_check_failed"))
+	e.runOnce(t)
+
+	if mapping, _ := e.db.GetAlertTicketMapping(e.ctx, "a1"); mapping == nil || mapping.ClientID != "c1" {
+		t.Fatalf("mapping = %+v, want it saved against the device's client c1", mapping)
+	}
+
+	closeTicketAsTech(t, e, "a1")
+	e.runOnce(t)
+
+	if e.closedInSlide("a1") {
+		t.Error("alert closed although client c1 requires a successful backup first")
+	}
+	if e.ticketFor(t, "a1").ClosedFlag {
+		t.Error("ticket left closed although client c1's policy blocked closing its alert")
+	}
+}
+
+func TestClientConnectWiseAuthoritativeAppliesToMSPAccountAlerts(t *testing.T) {
+	e := newTestEnv(t)
+	if err := e.db.SetClientSyncPolicy(e.ctx, "c1", models.SyncConnectWiseAuthoritative); err != nil {
+		t.Fatal(err)
+	}
+	e.slide.AddAlerts(mspAlert("a1", "agent1", "custom_check_failed"))
+	e.runOnce(t)
+
+	if err := e.slide.Client().CloseAlert(e.ctx, "a1"); err != nil {
+		t.Fatal(err)
+	}
+	e.runOnce(t)
+
+	ticket := e.ticketFor(t, "a1")
+	if ticket.ClosedFlag {
+		t.Error("ticket closed although client c1 leaves closing tickets to ConnectWise")
+	}
+	if !e.hasNote(ticket.ID, "leaves closing this ticket to ConnectWise") {
+		t.Error("ticket has no note that its alert resolved")
+	}
+}
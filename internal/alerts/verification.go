@@ -0,0 +1,128 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"slide-cw-integration/internal/connectwise"
+	"slide-cw-integration/pkg/models"
+)
+
+// defaultVerifyStatus is the ConnectWise status a ticket waits in when its policy names none
+const defaultVerifyStatus = "Pending Verification"
+
+// verifyStatus is the status alertType's tickets wait in while their backups are verified
+func (m *Monitor) verifyStatus(ctx context.Context, alertType string) string {
+	policy, err := m.db.GetAlertTypePolicy(ctx, alertType)
+	if err != nil {
+		log.Printf("Warning: failed to load policy for alert type %s: %v", alertType, err)
+	}
+	if policy == nil || policy.VerifyStatus == "" {
+		return defaultVerifyStatus
+	}
+	return policy.VerifyStatus
+}
+
+// awaitVerification holds alert open while its policy wants more successful backups. The
+// first time, its ticket moves to the pending verification status; each time the evidence
+// grows the ticket gets a note.
+func (m *Monitor) awaitVerification(ctx context.Context, alert *models.SlideAlert, resolution *models.AlertResolution) error {
+	mapping, err := m.mappingService.GetAlertTicketMapping(ctx, alert.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get alert-ticket mapping: %w", err)
+	}
+	if mapping == nil || mapping.ClosedAt != nil {
+		log.Printf("Alert %s is pending verification: %s", alert.ID, resolution.Evidence)
+		return nil
+	}
+	if mapping.VerifyingSince != nil && mapping.Resolution == resolution.Evidence {
+		return nil
+	}
+
+	if mapping.VerifyingSince == nil {
+		now := time.Now()
+		if err := m.db.SetAlertVerifying(ctx, alert.ID, &now); err != nil {
+			return fmt.Errorf("failed to mark alert %s pending verification: %w", alert.ID, err)
+		}
+		m.moveToVerifyStatus(ctx, alert, mapping.TicketID)
+	}
+	if err := m.db.SetAlertResolution(ctx, alert.ID, resolution.Evidence); err != nil {
+		log.Printf("Warning: failed to record verification progress of alert %s: %v", alert.ID, err)
+	}
+
+	m.addTicketNote(ctx, mapping.TicketID, connectwise.NoteInternal, fmt.Sprintf(
+		"Pending verification: Slide alert %s (%s) looks resolved, but stays open until its backups are verified.\nEvidence: %s",
+		alert.ID, alert.Type, resolution.Evidence))
+
+	log.Printf("Alert %s is pending verification on ticket %d: %s", alert.ID, mapping.TicketID, resolution.Evidence)
+	return nil
+}
+
+// moveToVerifyStatus puts ticketID in the pending verification status, unless it is
+// closed or carries other alerts that are still unresolved
+func (m *Monitor) moveToVerifyStatus(ctx context.Context, alert *models.SlideAlert, ticketID int) {
+	open, err := m.db.GetOpenAlertTicketMappingsByTicket(ctx, ticketID)
+	if err != nil {
+		log.Printf("Warning: failed to check other alerts on ticket %d: %v", ticketID, err)
+		return
+	}
+	for _, other := range open {
+		if other.AlertID != alert.ID && other.VerifyingSince == nil {
+			return
+		}
+	}
+
+	ticket, err := m.connectWise.GetTicket(ctx, ticketID)
+	if err != nil {
+		log.Printf("Warning: failed to get ticket %d: %v", ticketID, err)
+		return
+	}
+	if ticket.IsClosed() {
+		return
+	}
+
+	status := m.verifyStatus(ctx, alert.Type)
+	if err := m.connectWise.UpdateTicket(ctx, ticketID, status); err != nil {
+		log.Printf("Warning: failed to move ticket %d to %q: %v", ticketID, status, err)
+	}
+}
+
+// cancelVerification puts a ticket that was pending verification back to work once
+// alert's problem is back, e.g. a backup failed before enough succeeded in a row
+func (m *Monitor) cancelVerification(ctx context.Context, alert *models.SlideAlert) {
+	mapping, err := m.mappingService.GetAlertTicketMapping(ctx, alert.ID)
+	if err != nil || mapping == nil || mapping.VerifyingSince == nil || mapping.ClosedAt != nil {
+		return
+	}
+
+	if err := m.db.SetAlertVerifying(ctx, alert.ID, nil); err != nil {
+		log.Printf("Warning: failed to clear pending verification of alert %s: %v", alert.ID, err)
+		return
+	}
+	if err := m.db.SetAlertResolution(ctx, alert.ID, ""); err != nil {
+		log.Printf("Warning: failed to clear verification progress of alert %s: %v", alert.ID, err)
+	}
+
+	note := fmt.Sprintf("Verification failed: the latest backup did not succeed, so Slide alert %s (%s) is still active.",
+		alert.ID, alert.Type)
+
+	// Leave the status alone if a technician has already moved the ticket on
+	ticket, err := m.connectWise.GetTicket(ctx, mapping.TicketID)
+	if err != nil {
+		log.Printf("Warning: failed to get ticket %d: %v", mapping.TicketID, err)
+	} else if !ticket.IsClosed() && strings.EqualFold(ticket.Status.Name, m.verifyStatus(ctx, alert.Type)) {
+		if status, err := m.openStatus(ctx, ticket); err != nil {
+			log.Printf("Warning: %v", err)
+		} else if err := m.connectWise.UpdateTicket(ctx, ticket.ID, status); err != nil {
+			log.Printf("Warning: failed to move ticket %d back to %q: %v", ticket.ID, status, err)
+		} else {
+			note += fmt.Sprintf(" Moved back to %s.", status)
+		}
+	}
+
+	m.addTicketNote(ctx, mapping.TicketID, connectwise.NoteInternal, note)
+	log.Printf("Alert %s failed verification; ticket %d is active again", alert.ID, mapping.TicketID)
+}
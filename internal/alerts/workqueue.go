@@ -0,0 +1,224 @@
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"slide-cw-integration/internal/httpclient"
+	"slide-cw-integration/pkg/models"
+)
+
+const (
+	// workQueueInterval is how often the worker looks for due retries
+	workQueueInterval = 30 * time.Second
+
+	// workQueueBatch caps how many items one pass of the worker attempts
+	workQueueBatch = 20
+
+	// workMaxAttempts is how many times an item is tried before it is marked failed
+	workMaxAttempts = 8
+
+	workBaseBackoff = time.Minute
+	workMaxBackoff  = time.Hour
+)
+
+// workBackoff is the delay before the next try of an item that has failed attempts times
+func workBackoff(attempts int) time.Duration {
+	delay := workBaseBackoff
+	for i := 1; i < attempts && delay < workMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > workMaxBackoff {
+		delay = workMaxBackoff
+	}
+	return delay
+}
+
+// isPermanent reports whether retrying err can't help, e.g. ConnectWise rejecting the request body
+func isPermanent(err error) bool {
+	var apiErr *httpclient.APIError
+	return errors.As(err, &apiErr) && apiErr.IsValidation()
+}
+
+func isNotFound(err error) bool {
+	var apiErr *httpclient.APIError
+	return errors.As(err, &apiErr) && apiErr.IsNotFound()
+}
+
+// enqueueWork records a failed operation for the worker to retry with backoff
+func (m *Monitor) enqueueWork(ctx context.Context, item models.WorkItem, cause error) {
+	item.Status = models.WorkPending
+	item.Attempts = 1
+	item.NextAttemptAt = time.Now().Add(workBackoff(1))
+	item.LastError = cause.Error()
+
+	if isPermanent(cause) {
+		item.Status = models.WorkFailed
+	}
+
+	if err := m.db.EnqueueWorkItem(ctx, &item); err != nil {
+		log.Printf("Warning: failed to queue %s for alert %s: %v", item.Action, item.AlertID, err)
+		return
+	}
+
+	log.Printf("Queued %s for alert %s (%s)", item.Action, item.AlertID, item.Status)
+}
+
+// isQueued reports whether action is already waiting in the work queue for alertID, or
+// was discarded there by an admin
+func (m *Monitor) isQueued(ctx context.Context, action models.WorkAction, alertID string) bool {
+	item, err := m.db.GetWorkItem(ctx, action, alertID)
+	if err != nil {
+		log.Printf("Warning: failed to check work queue for alert %s: %v", alertID, err)
+		return false
+	}
+	return item != nil
+}
+
+// discardWork removes action's queued item for alertID, if there is one, once it is no
+// longer wanted
+func (m *Monitor) discardWork(ctx context.Context, action models.WorkAction, alertID string) {
+	item, err := m.db.GetWorkItem(ctx, action, alertID)
+	if err != nil {
+		log.Printf("Warning: failed to check work queue for alert %s: %v", alertID, err)
+		return
+	}
+	if item == nil {
+		return
+	}
+
+	if err := m.db.DeleteWorkItem(ctx, item.ID); err != nil {
+		log.Printf("Warning: failed to remove work item %d: %v", item.ID, err)
+		return
+	}
+	log.Printf("Discarded queued %s for alert %s", action, alertID)
+}
+
+// clearSkippedCreations removes the ticket creations an admin discarded once their alerts
+// are no longer among the unresolved ones, so skipping an alert doesn't outlive it
+func (m *Monitor) clearSkippedCreations(ctx context.Context, alerts []models.SlideAlert) {
+	items, err := m.db.GetWorkItemsByStatus(ctx, models.WorkSkipped)
+	if err != nil {
+		log.Printf("Warning: failed to load skipped ticket creations: %v", err)
+		return
+	}
+	if len(items) == 0 {
+		return
+	}
+
+	unresolved := make(map[string]bool)
+	for _, alert := range alerts {
+		if !alert.Resolved {
+			unresolved[alert.ID] = true
+		}
+	}
+
+	for _, item := range items {
+		if item.Action != models.WorkCreateTicket || unresolved[item.AlertID] {
+			continue
+		}
+		if err := m.db.DeleteWorkItem(ctx, item.ID); err != nil {
+			log.Printf("Warning: failed to remove work item %d: %v", item.ID, err)
+			continue
+		}
+		log.Printf("Alert %s is no longer unresolved, cleared its skipped ticket creation", item.AlertID)
+	}
+}
+
+// ProcessWorkQueue retries the queued operations that are due. Successful items are
+// removed; the rest back off until they run out of attempts and are marked failed.
+func (m *Monitor) ProcessWorkQueue(ctx context.Context) error {
+	items, err := m.db.GetDueWorkItems(ctx, time.Now(), workQueueBatch)
+	if err != nil {
+		return fmt.Errorf("failed to get due work items: %w", err)
+	}
+
+	for _, item := range items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		m.alertMu.Lock()
+		err := m.performWork(ctx, &item)
+		m.alertMu.Unlock()
+
+		if err == nil {
+			log.Printf("Retried %s for alert %s successfully after %d failed attempts",
+				item.Action, item.AlertID, item.Attempts)
+			if err := m.db.DeleteWorkItem(ctx, item.ID); err != nil {
+				log.Printf("Warning: failed to remove work item %d: %v", item.ID, err)
+			}
+			continue
+		}
+
+		item.Attempts++
+		item.LastError = err.Error()
+		item.NextAttemptAt = time.Now().Add(workBackoff(item.Attempts))
+		if item.Attempts >= workMaxAttempts || isPermanent(err) {
+			item.Status = models.WorkFailed
+			log.Printf("Giving up on %s for alert %s after %d attempts: %v",
+				item.Action, item.AlertID, item.Attempts, err)
+		} else {
+			log.Printf("Retry %d of %s for alert %s failed, next attempt at %s: %v",
+				item.Attempts, item.Action, item.AlertID, item.NextAttemptAt.Format(time.RFC3339), err)
+		}
+
+		if err := m.db.UpdateWorkItem(ctx, &item); err != nil {
+			log.Printf("Warning: failed to update work item %d: %v", item.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// performWork carries out one queued operation
+func (m *Monitor) performWork(ctx context.Context, item *models.WorkItem) error {
+	switch item.Action {
+	case models.WorkCreateTicket:
+		existing, err := m.mappingService.GetAlertTicketMapping(ctx, item.AlertID)
+		if err != nil {
+			return fmt.Errorf("failed to check existing ticket mapping: %w", err)
+		}
+		if existing != nil {
+			return nil
+		}
+
+		// The alert may have resolved, or a maintenance window opened, since it was queued
+		alert, err := m.slideClient.GetAlert(ctx, item.AlertID)
+		if err != nil {
+			if isNotFound(err) {
+				log.Printf("Alert %s no longer exists; dropping queued ticket creation", item.AlertID)
+				return nil
+			}
+			return fmt.Errorf("failed to get alert %s: %w", item.AlertID, err)
+		}
+		if alert.Resolved {
+			log.Printf("Alert %s resolved while queued; dropping ticket creation", item.AlertID)
+			return nil
+		}
+		if active, err := m.applyResolution(ctx, alert); !active {
+			return err
+		}
+		return m.createTicket(ctx, alert)
+
+	case models.WorkCloseTicket:
+		if err := m.connectWise.CloseTicket(ctx, item.TicketID); err != nil && !isNotFound(err) {
+			return fmt.Errorf("failed to close ConnectWise ticket %d: %w", item.TicketID, err)
+		}
+		return m.mappingService.CloseAlertTicketMapping(ctx, item.AlertID)
+
+	case models.WorkCloseAlert:
+		if err := m.slideClient.CloseAlert(ctx, item.AlertID); err != nil {
+			return fmt.Errorf("failed to close alert in Slide: %w", err)
+		}
+		if item.TicketID != 0 {
+			return m.mappingService.CloseAlertTicketMapping(ctx, item.AlertID)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("unknown work action %q", item.Action)
+}
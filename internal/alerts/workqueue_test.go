@@ -0,0 +1,156 @@
+package alerts
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"slide-cw-integration/internal/connectwise/cwtest"
+	"slide-cw-integration/pkg/models"
+)
+
+// queuedCreate fails ticket creation for alert a1 once so it lands in the work queue,
+// and returns the queued item made due now
+func queuedCreate(t *testing.T, e *testEnv) models.WorkItem {
+	t.Helper()
+
+	e.slide.AddAlerts(testAlert("a1", "agent1", "backup_failed", time.Now().Add(-time.Hour)))
+	e.cw.Fail(cwtest.Failure{Method: http.MethodPost, Path: "/service/tickets", Status: http.StatusInternalServerError})
+	e.runOnce(t)
+
+	item, err := e.db.GetWorkItem(e.ctx, models.WorkCreateTicket, "a1")
+	if err != nil || item == nil {
+		t.Fatalf("ticket creation was not queued (err %v)", err)
+	}
+	if err := e.db.RetryWorkItem(e.ctx, item.ID); err != nil {
+		t.Fatal(err)
+	}
+	return *item
+}
+
+func TestWorkQueueRetriesTicketCreation(t *testing.T) {
+	e := newTestEnv(t)
+	queuedCreate(t, e)
+
+	// Polling leaves the retry to the queue rather than creating the ticket itself
+	e.runOnce(t)
+	if n := len(e.cw.Tickets()); n != 0 {
+		t.Fatalf("got %d tickets while creation was queued, want 0", n)
+	}
+
+	if err := e.mon.ProcessWorkQueue(e.ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	e.ticketFor(t, "a1")
+	if item, _ := e.db.GetWorkItem(e.ctx, models.WorkCreateTicket, "a1"); item != nil {
+		t.Errorf("work item still queued after a successful retry: %+v", item)
+	}
+}
+
+func TestWorkQueueDropsCreationForResolvedAlert(t *testing.T) {
+	e := newTestEnv(t)
+	queuedCreate(t, e)
+
+	if err := e.slide.Client().CloseAlert(e.ctx, "a1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.mon.ProcessWorkQueue(e.ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(e.cw.Tickets()); n != 0 {
+		t.Errorf("got %d tickets for an alert resolved while queued, want 0", n)
+	}
+	if item, _ := e.db.GetWorkItem(e.ctx, models.WorkCreateTicket, "a1"); item != nil {
+		t.Errorf("work item still queued for a resolved alert: %+v", item)
+	}
+}
+
+func TestDiscardedCreationSkipsAlert(t *testing.T) {
+	e := newTestEnv(t)
+	item := queuedCreate(t, e)
+
+	if err := e.db.DiscardWorkItem(e.ctx, item.ID); err != nil {
+		t.Fatal(err)
+	}
+	e.runOnce(t)
+	if err := e.mon.ProcessWorkQueue(e.ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(e.cw.Tickets()); n != 0 {
+		t.Fatalf("got %d tickets for an alert whose creation was discarded, want 0", n)
+	}
+	if item, _ := e.db.GetWorkItem(e.ctx, models.WorkCreateTicket, "a1"); item == nil || item.Status != models.WorkSkipped {
+		t.Fatalf("discarded creation = %+v, want it kept as skipped", item)
+	}
+
+	// Retrying the discarded item tickets the alert after all
+	if err := e.db.RetryWorkItem(e.ctx, item.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.mon.ProcessWorkQueue(e.ctx); err != nil {
+		t.Fatal(err)
+	}
+	e.ticketFor(t, "a1")
+}
+
+func TestDiscardedCreationClearedWhenAlertResolves(t *testing.T) {
+	e := newTestEnv(t)
+	item := queuedCreate(t, e)
+	if err := e.db.DiscardWorkItem(e.ctx, item.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.slide.Client().CloseAlert(e.ctx, "a1"); err != nil {
+		t.Fatal(err)
+	}
+	e.runOnce(t)
+
+	if item, _ := e.db.GetWorkItem(e.ctx, models.WorkCreateTicket, "a1"); item != nil {
+		t.Errorf("skipped creation still queued after the alert resolved: %+v", item)
+	}
+}
+
+func TestWorkQueueDropsCreationWhenResolverClosesAlert(t *testing.T) {
+	e := newTestEnv(t)
+	queuedCreate(t, e)
+
+	completed := time.Now()
+	e.slide.AddBackups(models.SlideBackup{ID: "b1", AgentID: "agent1", CompletedAt: &completed, Success: true})
+	e.runOnce(t)
+
+	if !e.closedInSlide("a1") {
+		t.Fatal("alert was not closed after a successful backup")
+	}
+	if item, _ := e.db.GetWorkItem(e.ctx, models.WorkCreateTicket, "a1"); item != nil {
+		t.Fatalf("work item still queued after the alert resolved: %+v", item)
+	}
+	if n := len(e.cw.Tickets()); n != 0 {
+		t.Errorf("got %d tickets for a resolved alert, want 0", n)
+	}
+}
+
+func TestWorkQueueHoldsCreationDuringMaintenance(t *testing.T) {
+	e := newTestEnv(t)
+	queuedCreate(t, e)
+
+	if err := e.db.SaveMaintenanceWindow(e.ctx, &models.MaintenanceWindow{
+		Name:            "Server migration",
+		Scope:           models.MaintenanceClient,
+		ScopeID:         "c1",
+		Timezone:        "UTC",
+		StartsAt:        time.Now().UTC().Add(-time.Minute).Format(models.MaintenanceTimeFormat),
+		DurationMinutes: 60,
+		Recurrence:      models.RecurOnce,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.mon.ProcessWorkQueue(e.ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(e.cw.Tickets()); n != 0 {
+		t.Errorf("got %d tickets during a maintenance window, want 0", n)
+	}
+}
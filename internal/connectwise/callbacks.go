@@ -0,0 +1,216 @@
+package connectwise
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"slide-cw-integration/pkg/models"
+)
+
+// CallbackDescription marks the callbacks this integration owns in system/callbacks
+const CallbackDescription = "Slide integration - ticket updates"
+
+// signingKeyTTL is how long a callback signing key is reused before being fetched again
+const signingKeyTTL = time.Hour
+
+// CallbackPayload is the body ConnectWise POSTs to a callback URL
+type CallbackPayload struct {
+	MessageID string `json:"MessageId"`
+	Action    string `json:"Action"`
+	Type      string `json:"Type"`
+	ID        int    `json:"ID"`
+	Entity    string `json:"Entity"`
+	Metadata  struct {
+		KeyURL string `json:"key_url"`
+	} `json:"Metadata"`
+}
+
+type signingKey struct {
+	key     []byte
+	fetched time.Time
+}
+
+// GetCallbacks lists every callback subscription for the integration's API member
+func (c *Client) GetCallbacks(ctx context.Context) ([]models.ConnectWiseCallback, error) {
+	var allCallbacks []models.ConnectWiseCallback
+	page := 1
+	pageSize := 1000
+
+	for {
+		endpoint := fmt.Sprintf("/system/callbacks?page=%d&pageSize=%d", page, pageSize)
+
+		var callbacks []models.ConnectWiseCallback
+		if err := c.makeRequest(ctx, "GET", endpoint, nil, &callbacks); err != nil {
+			return nil, fmt.Errorf("failed to get callbacks (page %d): %w", page, err)
+		}
+
+		allCallbacks = append(allCallbacks, callbacks...)
+
+		if len(callbacks) < pageSize {
+			break
+		}
+
+		page++
+	}
+
+	return allCallbacks, nil
+}
+
+// CreateCallback subscribes a URL to ConnectWise callbacks
+func (c *Client) CreateCallback(ctx context.Context, callback models.ConnectWiseCallback) (*models.ConnectWiseCallback, error) {
+	var result models.ConnectWiseCallback
+	if err := c.makeRequest(ctx, "POST", "/system/callbacks", callback, &result); err != nil {
+		return nil, fmt.Errorf("failed to create callback: %w", err)
+	}
+	return &result, nil
+}
+
+// DeleteCallback removes a callback subscription
+func (c *Client) DeleteCallback(ctx context.Context, callbackID int) error {
+	endpoint := fmt.Sprintf("/system/callbacks/%d", callbackID)
+	if err := c.makeRequest(ctx, "DELETE", endpoint, nil, nil); err != nil {
+		return fmt.Errorf("failed to delete callback %d: %w", callbackID, err)
+	}
+	return nil
+}
+
+// EnsureTicketCallbacks makes sure ticket updates on each of boardIDs are delivered to
+// callbackURL. Existing matching subscriptions are reused; ours pointing anywhere else, or
+// at a board no longer in boardIDs, are removed.
+func (c *Client) EnsureTicketCallbacks(ctx context.Context, callbackURL string, boardIDs []int) ([]models.ConnectWiseCallback, error) {
+	callbacks, err := c.GetCallbacks(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[int]bool)
+	for _, boardID := range boardIDs {
+		wanted[boardID] = true
+	}
+
+	current := make(map[int]models.ConnectWiseCallback)
+	for _, callback := range callbacks {
+		if callback.Description != CallbackDescription {
+			continue
+		}
+
+		if _, seen := current[callback.ObjectID]; !seen && wanted[callback.ObjectID] && !callback.InactiveFlag &&
+			callback.URL == callbackURL && strings.EqualFold(callback.Type, "ticket") {
+			current[callback.ObjectID] = callback
+			continue
+		}
+
+		// Left over from an old URL, token or board
+		log.Printf("Removing stale ConnectWise callback %d (board %d)", callback.ID, callback.ObjectID)
+		if err := c.DeleteCallback(ctx, callback.ID); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	var registered []models.ConnectWiseCallback
+	for _, boardID := range boardIDs {
+		if callback, ok := current[boardID]; ok {
+			log.Printf("ConnectWise callback %d already registered for board %d", callback.ID, boardID)
+			registered = append(registered, callback)
+			continue
+		}
+
+		created, err := c.CreateCallback(ctx, models.ConnectWiseCallback{
+			Description: CallbackDescription,
+			URL:         callbackURL,
+			ObjectID:    boardID,
+			Type:        "ticket",
+			Level:       "board",
+		})
+		if err != nil {
+			return registered, fmt.Errorf("failed to register callback for board %d: %w", boardID, err)
+		}
+
+		log.Printf("Registered ConnectWise callback %d for ticket updates on board %d", created.ID, boardID)
+		registered = append(registered, *created)
+	}
+
+	return registered, nil
+}
+
+// ParseCallback decodes a callback body and checks its x-content-signature against the
+// signing key ConnectWise publishes at the payload's key_url
+func (c *Client) ParseCallback(ctx context.Context, body []byte, signature string) (*CallbackPayload, error) {
+	var payload CallbackPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid callback body: %w", err)
+	}
+
+	if payload.Metadata.KeyURL == "" {
+		return nil, fmt.Errorf("callback has no signing key URL")
+	}
+	if signature == "" {
+		return nil, fmt.Errorf("callback is missing its signature")
+	}
+
+	key, err := c.callbackSigningKey(ctx, payload.Metadata.KeyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	given, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, fmt.Errorf("callback signature is not valid base64: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	if !hmac.Equal(given, mac.Sum(nil)) {
+		return nil, fmt.Errorf("callback signature does not match")
+	}
+
+	return &payload, nil
+}
+
+// callbackSigningKey fetches (or reuses) the key at keyURL. The URL comes from the
+// unauthenticated request body, so it must point at our own ConnectWise host.
+func (c *Client) callbackSigningKey(ctx context.Context, keyURL string) ([]byte, error) {
+	parsed, err := url.Parse(keyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key URL: %w", err)
+	}
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ConnectWise base URL: %w", err)
+	}
+	if parsed.Scheme != base.Scheme || !strings.EqualFold(parsed.Host, base.Host) {
+		return nil, fmt.Errorf("signing key URL host %q does not match ConnectWise host %q", parsed.Host, base.Host)
+	}
+
+	c.keyMu.Lock()
+	cached, ok := c.signingKeys[keyURL]
+	c.keyMu.Unlock()
+	if ok && time.Since(cached.fetched) < signingKeyTTL {
+		return cached.key, nil
+	}
+
+	var result struct {
+		SigningKey string `json:"SigningKey"`
+	}
+	if err := c.doRequest(ctx, "GET", keyURL, parsed.Path, nil, &result); err != nil {
+		return nil, fmt.Errorf("failed to fetch callback signing key: %w", err)
+	}
+	if result.SigningKey == "" {
+		return nil, fmt.Errorf("ConnectWise returned an empty signing key")
+	}
+
+	key := []byte(result.SigningKey)
+	c.keyMu.Lock()
+	c.signingKeys[keyURL] = signingKey{key: key, fetched: time.Now()}
+	c.keyMu.Unlock()
+
+	return key, nil
+}
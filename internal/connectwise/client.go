@@ -2,16 +2,26 @@ package connectwise
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
-	"io"
+	"slide-cw-integration/internal/httpclient"
 	"slide-cw-integration/pkg/models"
 )
 
+// requestTimeout is the overall budget for one API call, including retries
+const requestTimeout = 2 * time.Minute
+
 type Client struct {
 	baseURL    string
 	companyID  string
@@ -19,6 +29,15 @@ type Client struct {
 	privateKey string
 	clientID   string
 	httpClient *http.Client
+
+	// statusMu guards the per-board closed status cache and resolved status overrides
+	statusMu         sync.Mutex
+	closedStatuses   map[int]models.ConnectWiseStatus
+	resolvedStatuses map[int]models.BoardResolvedStatus
+
+	// keyMu guards the callback signing keys, cached by key URL
+	keyMu       sync.Mutex
+	signingKeys map[string]signingKey
 }
 
 type CompanyResponse struct {
@@ -31,10 +50,22 @@ type TicketCreateRequest struct {
 	Board       BoardRef `json:"board"`
 	Status      StatusRef `json:"status,omitempty"`
 	Priority    PriorityRef `json:"priority,omitempty"`
-	Type        TypeRef `json:"type,omitempty"`
+	Type        *TypeRef `json:"type,omitempty"`
+	SubType     *SubTypeRef `json:"subType,omitempty"`
+	Item        *ItemRef `json:"item,omitempty"`
+	Owner       *MemberRef `json:"owner,omitempty"`
 	Description string `json:"initialDescription,omitempty"`
 }
 
+// ScheduleEntryRequest puts a member on a ticket; with dates it also books their calendar
+type ScheduleEntryRequest struct {
+	ObjectID  int             `json:"objectId"`
+	Member    MemberRef       `json:"member"`
+	Type      ScheduleTypeRef `json:"type"`
+	DateStart *time.Time      `json:"dateStart,omitempty"`
+	DateEnd   *time.Time      `json:"dateEnd,omitempty"`
+}
+
 type CompanyRef struct {
 	ID int `json:"id"`
 }
@@ -44,7 +75,8 @@ type BoardRef struct {
 }
 
 type StatusRef struct {
-	Name string `json:"name"`
+	ID   int    `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
 }
 
 type PriorityRef struct {
@@ -55,20 +87,40 @@ type TypeRef struct {
 	Name string `json:"name"`
 }
 
+type SubTypeRef struct {
+	Name string `json:"name"`
+}
+
+type ItemRef struct {
+	Name string `json:"name"`
+}
+
+type MemberRef struct {
+	ID int `json:"id"`
+}
+
+type ScheduleTypeRef struct {
+	Identifier string `json:"identifier"`
+}
+
+// scheduleTypeServiceTicket is the schedule type for entries attached to service tickets
+const scheduleTypeServiceTicket = "S"
+
 func NewClient(baseURL, companyID, publicKey, privateKey, clientID string) *Client {
 	return &Client{
-		baseURL:    baseURL,
-		companyID:  companyID,
-		publicKey:  publicKey,
-		privateKey: privateKey,
-		clientID:   clientID,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:          baseURL,
+		companyID:        companyID,
+		publicKey:        publicKey,
+		privateKey:       privateKey,
+		clientID:         clientID,
+		httpClient:       httpclient.New(httpclient.DefaultConfig("ConnectWise"), requestTimeout),
+		closedStatuses:   make(map[int]models.ConnectWiseStatus),
+		resolvedStatuses: make(map[int]models.BoardResolvedStatus),
+		signingKeys:      make(map[string]signingKey),
 	}
 }
 
-func (c *Client) GetClients() ([]models.ConnectWiseClient, error) {
+func (c *Client) GetClients(ctx context.Context) ([]models.ConnectWiseClient, error) {
 	var allCompanies []models.ConnectWiseClient
 	page := 1
 	pageSize := 1000 // Maximum page size for ConnectWise
@@ -79,7 +131,7 @@ func (c *Client) GetClients() ([]models.ConnectWiseClient, error) {
 		endpoint := fmt.Sprintf("/company/companies?conditions=deletedFlag=false&page=%d&pageSize=%d&orderBy=name", page, pageSize)
 
 		var companies []models.ConnectWiseClient
-		if err := c.makeRequest("GET", endpoint, nil, &companies); err != nil {
+		if err := c.makeRequest(ctx, "GET", endpoint, nil, &companies); err != nil {
 			return nil, fmt.Errorf("failed to get companies (page %d): %w", page, err)
 		}
 
@@ -103,47 +155,104 @@ func (c *Client) GetClients() ([]models.ConnectWiseClient, error) {
 	return allCompanies, nil
 }
 
-func (c *Client) CreateTicket(companyID int, summary, description string) (*models.ConnectWiseTicket, error) {
+func (c *Client) CreateTicket(ctx context.Context, companyID int, summary, description string) (*models.ConnectWiseTicket, error) {
 	ticket := TicketCreateRequest{
 		Summary: summary,
 		Company: CompanyRef{ID: companyID},
 		Board:   BoardRef{Name: "Service Board"}, // Default board
 		Status:  StatusRef{Name: "New"},
 		Priority: PriorityRef{Name: "Medium"},
-		Type:     TypeRef{Name: "Issue"},
+		Type:     &TypeRef{Name: "Issue"},
 		Description: description,
 	}
 
 	var result models.ConnectWiseTicket
-	if err := c.makeRequest("POST", "/service/tickets", ticket, &result); err != nil {
+	if err := c.makeRequest(ctx, "POST", "/service/tickets", ticket, &result); err != nil {
 		return nil, fmt.Errorf("failed to create ticket: %w", err)
 	}
 	return &result, nil
 }
 
-func (c *Client) CreateTicketWithConfig(companyID int, summary, description string, config *models.TicketingConfig) (*models.ConnectWiseTicket, error) {
+func (c *Client) CreateTicketWithConfig(ctx context.Context, companyID int, summary, description string, config *models.TicketingConfig) (*models.ConnectWiseTicket, error) {
 	ticket := TicketCreateRequest{
 		Summary: summary,
 		Company: CompanyRef{ID: companyID},
 		Board:   BoardRef{Name: config.BoardName},
 		Status:  StatusRef{Name: config.StatusName},
 		Priority: PriorityRef{Name: config.PriorityName},
-		Type:     TypeRef{Name: config.TypeName},
 		Description: description,
 	}
+	// A routing rule that moves the ticket to another board may leave the type unset
+	if config.TypeName != "" {
+		ticket.Type = &TypeRef{Name: config.TypeName}
+	}
+	if config.SubTypeName != "" {
+		ticket.SubType = &SubTypeRef{Name: config.SubTypeName}
+	}
+	if config.ItemName != "" {
+		ticket.Item = &ItemRef{Name: config.ItemName}
+	}
+
+	technicianID := 0
+	if config.AutoAssignTech && config.TechnicianID != nil {
+		technicianID = *config.TechnicianID
+	}
+	if technicianID != 0 && config.AssignOwner {
+		ticket.Owner = &MemberRef{ID: technicianID}
+	}
 
 	log.Printf("Creating ticket with Company ID: %d, Summary: %s", companyID, summary)
 	log.Printf("Board: %s, Status: %s, Priority: %s, Type: %s",
 		config.BoardName, config.StatusName, config.PriorityName, config.TypeName)
 
 	var result models.ConnectWiseTicket
-	if err := c.makeRequest("POST", "/service/tickets", ticket, &result); err != nil {
+	if err := c.makeRequest(ctx, "POST", "/service/tickets", ticket, &result); err != nil {
 		return nil, fmt.Errorf("failed to create ticket: %w", err)
 	}
 
 	log.Printf("Created ticket %d for company %d (%s)", result.ID, result.Company.ID, result.Company.Name)
+
+	// The ticket exists at this point, so a failed assignment is only logged -
+	// returning an error would make the caller create a duplicate ticket
+	if technicianID != 0 && (config.AssignResource || config.ScheduleTech) {
+		var start, end *time.Time
+		if config.ScheduleTech {
+			minutes := config.ScheduleMinutes
+			if minutes <= 0 {
+				minutes = 60
+			}
+			startAt := time.Now().UTC().Truncate(time.Minute)
+			endAt := startAt.Add(time.Duration(minutes) * time.Minute)
+			start, end = &startAt, &endAt
+		}
+
+		if err := c.AddScheduleEntry(ctx, result.ID, technicianID, start, end); err != nil {
+			log.Printf("Warning: created ticket %d but failed to assign technician %s (%d): %v",
+				result.ID, config.TechnicianName, technicianID, err)
+		} else {
+			log.Printf("Assigned technician %s (%d) to ticket %d", config.TechnicianName, technicianID, result.ID)
+		}
+	}
+
 	return &result, nil
 }
+
+// AddScheduleEntry adds a member as a resource on a ticket. When start and end
+// are set the entry is also booked on the member's schedule for that time.
+func (c *Client) AddScheduleEntry(ctx context.Context, ticketID, memberID int, start, end *time.Time) error {
+	entry := ScheduleEntryRequest{
+		ObjectID:  ticketID,
+		Member:    MemberRef{ID: memberID},
+		Type:      ScheduleTypeRef{Identifier: scheduleTypeServiceTicket},
+		DateStart: start,
+		DateEnd:   end,
+	}
+
+	if err := c.makeRequest(ctx, "POST", "/schedule/entries", entry, nil); err != nil {
+		return fmt.Errorf("failed to create schedule entry for ticket %d: %w", ticketID, err)
+	}
+	return nil
+}
 //CW error - we need to define the patch array
 type PatchDoc struct {
 	Op string `json:"op"`
@@ -152,22 +261,45 @@ type PatchDoc struct {
 }
 
 //endPatch
-func (c *Client) UpdateTicket(ticketID int, status string) error {
-	newStatusValue := StatusRef{Name: status}
+func (c *Client) UpdateTicket(ctx context.Context, ticketID int, status string) error {
+	return c.updateTicketStatus(ctx, ticketID, StatusRef{Name: status})
+}
+
+func (c *Client) updateTicketStatus(ctx context.Context, ticketID int, status StatusRef) error {
 	patchOp := PatchDoc{
 		Op: "replace",
 		Path: "/status",
-		Value: newStatusValue,
+		Value: status,
 	}
 	patchDocument :=[]PatchDoc{patchOp}
 
 	endpoint := fmt.Sprintf("/service/tickets/%d", ticketID)
-	return c.makeRequest("PATCH", endpoint, patchDocument, nil)
+	return c.makeRequest(ctx, "PATCH", endpoint, patchDocument, nil)
+}
+
+// EscalateTicket raises a ticket's priority and/or hands it to another owner.
+// An empty priority or nil owner leaves that field as it is.
+func (c *Client) EscalateTicket(ctx context.Context, ticketID int, priorityName string, ownerID *int) error {
+	var patchDocument []PatchDoc
+	if priorityName != "" {
+		patchDocument = append(patchDocument, PatchDoc{Op: "replace", Path: "/priority", Value: PriorityRef{Name: priorityName}})
+	}
+	if ownerID != nil {
+		patchDocument = append(patchDocument, PatchDoc{Op: "replace", Path: "/owner", Value: MemberRef{ID: *ownerID}})
+	}
+	if len(patchDocument) == 0 {
+		return nil
+	}
+
+	endpoint := fmt.Sprintf("/service/tickets/%d", ticketID)
+	return c.makeRequest(ctx, "PATCH", endpoint, patchDocument, nil)
 }
 
-func (c *Client) CloseTicket(ticketID int) error {
-	// Get the ticket first to check if already closed
-	ticket, err := c.GetTicket(ticketID)
+// CloseTicket moves a ticket to its board's resolved status: the admin's choice for
+// that board if one is set, otherwise the board's own closed status
+func (c *Client) CloseTicket(ctx context.Context, ticketID int) error {
+	// Get the ticket first to check if already closed and to learn its board
+	ticket, err := c.GetTicket(ctx, ticketID)
 	if err != nil {
 		return fmt.Errorf("failed to get ticket: %w", err)
 	}
@@ -178,29 +310,238 @@ func (c *Client) CloseTicket(ticketID int) error {
 		return nil
 	}
 
-	// Try common closed status names - Claude fail.
-	// ConnectWise boards typically have status names like "Closed", ">Closed", "Complete", etc.
-	closedStatuses := []string{">Closed", "Closed", "Complete", "Completed", "Resolved"}
+	status, err := c.ResolvedStatus(ctx, ticket.Board.ID)
+	if err != nil {
+		return fmt.Errorf("failed to close ticket %d: %w", ticketID, err)
+	}
+
+	if ticket.Status.ID == status.ID {
+		log.Printf("Ticket %d is already in resolved status '%s'", ticketID, status.Name)
+		return nil
+	}
 
-	for _, statusName := range closedStatuses {
-		log.Printf("Attempting to close ticket %d with status: %s", ticketID, statusName)
-		err := c.UpdateTicket(ticketID, statusName)
-		if err == nil {
-			log.Printf("Successfully closed ticket %d with status: %s", ticketID, statusName)
-			return nil
+	log.Printf("Closing ticket %d on board %d with status '%s' (%d)", ticketID, ticket.Board.ID, status.Name, status.ID)
+	if err := c.updateTicketStatus(ctx, ticketID, StatusRef{ID: status.ID}); err != nil {
+		// The status may have been renamed or deactivated since we cached it
+		var apiErr *httpclient.APIError
+		if errors.As(err, &apiErr) && apiErr.IsValidation() {
+			c.forgetClosedStatus(ticket.Board.ID)
 		}
-		log.Printf("Failed to close with status '%s': %v", statusName, err)
+		return fmt.Errorf("failed to close ticket %d with status '%s': %w", ticketID, status.Name, err)
+	}
+
+	log.Printf("Successfully closed ticket %d with status: %s", ticketID, status.Name)
+	return nil
+}
+
+// SetResolvedStatuses replaces the admin-chosen resolved status overrides, keyed by board
+func (c *Client) SetResolvedStatuses(statuses []models.BoardResolvedStatus) {
+	overrides := make(map[int]models.BoardResolvedStatus, len(statuses))
+	for _, status := range statuses {
+		overrides[status.BoardID] = status
 	}
 
-	return fmt.Errorf("failed to close ticket %d with any known closed status", ticketID)
+	c.statusMu.Lock()
+	c.resolvedStatuses = overrides
+	c.statusMu.Unlock()
+}
+
+// ResolvedStatus returns the status tickets on boardID should be closed with.
+// An admin override wins; otherwise the board's closed status is looked up once and cached.
+func (c *Client) ResolvedStatus(ctx context.Context, boardID int) (models.ConnectWiseStatus, error) {
+	c.statusMu.Lock()
+	override, hasOverride := c.resolvedStatuses[boardID]
+	cached, hasCached := c.closedStatuses[boardID]
+	c.statusMu.Unlock()
+
+	if hasOverride {
+		return models.ConnectWiseStatus{ID: override.StatusID, Name: override.StatusName, BoardID: boardID}, nil
+	}
+	if hasCached {
+		return cached, nil
+	}
+
+	statuses, err := c.GetStatuses(ctx, boardID)
+	if err != nil {
+		return models.ConnectWiseStatus{}, err
+	}
+
+	status, ok := pickClosedStatus(statuses)
+	if !ok {
+		return models.ConnectWiseStatus{}, fmt.Errorf("board %d has no active status flagged as closed", boardID)
+	}
+
+	c.statusMu.Lock()
+	c.closedStatuses[boardID] = status
+	c.statusMu.Unlock()
+
+	log.Printf("Using status '%s' (%d) to close tickets on board %d", status.Name, status.ID, boardID)
+	return status, nil
+}
+
+func (c *Client) forgetClosedStatus(boardID int) {
+	c.statusMu.Lock()
+	delete(c.closedStatuses, boardID)
+	c.statusMu.Unlock()
+}
+
+// pickClosedStatus chooses the board's first closed status by sort order, preferring
+// anything over a "cancelled" status since an auto-resolved alert wasn't cancelled
+func pickClosedStatus(statuses []models.ConnectWiseStatus) (models.ConnectWiseStatus, bool) {
+	var closed []models.ConnectWiseStatus
+	for _, status := range statuses {
+		if status.ClosedStatus && !status.Inactive {
+			closed = append(closed, status)
+		}
+	}
+	if len(closed) == 0 {
+		return models.ConnectWiseStatus{}, false
+	}
+
+	sort.SliceStable(closed, func(i, j int) bool {
+		iCancel := strings.Contains(strings.ToLower(closed[i].Name), "cancel")
+		jCancel := strings.Contains(strings.ToLower(closed[j].Name), "cancel")
+		if iCancel != jCancel {
+			return !iCancel
+		}
+		return closed[i].SortOrder < closed[j].SortOrder
+	})
+
+	return closed[0], true
+}
+
+// NoteType selects which section of a ticket a note is filed under
+type NoteType int
+
+const (
+	// NoteInternal is only visible to technicians (Internal Analysis)
+	NoteInternal NoteType = iota
+	// NoteResolution explains how the ticket was resolved
+	NoteResolution
+	// NoteDiscussion is part of the customer-visible detail description
+	NoteDiscussion
+)
+
+// TicketNoteRequest is the body of POST /service/tickets/{id}/notes
+type TicketNoteRequest struct {
+	Text                  string `json:"text"`
+	DetailDescriptionFlag bool   `json:"detailDescriptionFlag"`
+	InternalAnalysisFlag  bool   `json:"internalAnalysisFlag"`
+	ResolutionFlag        bool   `json:"resolutionFlag"`
+}
+
+// TicketNote is a note as returned by GET /service/tickets/{id}/notes
+type TicketNote struct {
+	ID                    int    `json:"id"`
+	Text                  string `json:"text"`
+	DetailDescriptionFlag bool   `json:"detailDescriptionFlag"`
+	InternalAnalysisFlag  bool   `json:"internalAnalysisFlag"`
+	ResolutionFlag        bool   `json:"resolutionFlag"`
+}
+
+// AddTicketNote posts a note to a ticket
+func (c *Client) AddTicketNote(ctx context.Context, ticketID int, text string, noteType NoteType) error {
+	note := TicketNoteRequest{
+		Text:                  text,
+		DetailDescriptionFlag: noteType == NoteDiscussion,
+		InternalAnalysisFlag:  noteType == NoteInternal,
+		ResolutionFlag:        noteType == NoteResolution,
+	}
+
+	endpoint := fmt.Sprintf("/service/tickets/%d/notes", ticketID)
+	if err := c.makeRequest(ctx, "POST", endpoint, note, nil); err != nil {
+		return fmt.Errorf("failed to add note to ticket %d: %w", ticketID, err)
+	}
+	return nil
+}
+
+// GetTicketNotes returns every note on a ticket, including the initial description
+func (c *Client) GetTicketNotes(ctx context.Context, ticketID int) ([]TicketNote, error) {
+	var allNotes []TicketNote
+	page := 1
+	pageSize := 1000
+
+	for {
+		endpoint := fmt.Sprintf("/service/tickets/%d/notes?page=%d&pageSize=%d", ticketID, page, pageSize)
+
+		var notes []TicketNote
+		if err := c.makeRequest(ctx, "GET", endpoint, nil, &notes); err != nil {
+			return nil, fmt.Errorf("failed to get notes for ticket %d (page %d): %w", ticketID, page, err)
+		}
+
+		allNotes = append(allNotes, notes...)
+
+		if len(notes) < pageSize {
+			break
+		}
+
+		page++
+	}
+
+	return allNotes, nil
+}
+
+// findTicketNoteScanLimit caps how many of the newest tickets FindTicketByNote reads the
+// notes of when searching by summary and initial description finds nothing
+const findTicketNoteScanLimit = 10
+
+// FindTicketByNote returns the first ticket for companyID entered since `since` whose
+// summary, initial description or notes contain text, or nil if there is none. ConnectWise
+// searches the summary and description itself; only the newest few tickets have their
+// notes read, one request each, in case the description was filed as a note instead.
+func (c *Client) FindTicketByNote(ctx context.Context, companyID int, since time.Time, text string) (*models.ConnectWiseTicket, error) {
+	base := fmt.Sprintf("company/id=%d and _info/dateEntered>=[%s]", companyID, since.UTC().Format(time.RFC3339))
+	quoted := strings.ReplaceAll(text, `"`, `\"`)
+
+	query := url.Values{}
+	query.Set("conditions", fmt.Sprintf(`%s and summary contains "%s" or %s and initialDescription contains "%s"`,
+		base, quoted, base, quoted))
+	query.Set("orderBy", "id asc")
+	query.Set("pageSize", "1")
+
+	var tickets []models.ConnectWiseTicket
+	err := c.makeRequest(ctx, "GET", "/service/tickets?"+query.Encode(), nil, &tickets)
+	if err == nil && len(tickets) > 0 {
+		return &tickets[0], nil
+	}
+	var apiErr *httpclient.APIError
+	if err != nil && !(errors.As(err, &apiErr) && apiErr.IsValidation()) {
+		return nil, fmt.Errorf("failed to search tickets for company %d: %w", companyID, err)
+	}
+	if err != nil {
+		log.Printf("Warning: ConnectWise rejected searching ticket descriptions, reading notes instead: %v", err)
+	}
+
+	query = url.Values{}
+	query.Set("conditions", base)
+	query.Set("orderBy", "id desc")
+	query.Set("pageSize", strconv.Itoa(findTicketNoteScanLimit))
+
+	tickets = nil
+	if err := c.makeRequest(ctx, "GET", "/service/tickets?"+query.Encode(), nil, &tickets); err != nil {
+		return nil, fmt.Errorf("failed to list recent tickets for company %d: %w", companyID, err)
+	}
+
+	for i := range tickets {
+		notes, err := c.GetTicketNotes(ctx, tickets[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, note := range notes {
+			if strings.Contains(note.Text, text) {
+				return &tickets[i], nil
+			}
+		}
+	}
+	return nil, nil
 }
 
 // GetTicket retrieves a specific ticket by ID
-func (c *Client) GetTicket(ticketID int) (*models.ConnectWiseTicket, error) {
+func (c *Client) GetTicket(ctx context.Context, ticketID int) (*models.ConnectWiseTicket, error) {
 	endpoint := fmt.Sprintf("/service/tickets/%d", ticketID)
 
 	var result models.ConnectWiseTicket
-	if err := c.makeRequest("GET", endpoint, nil, &result); err != nil {
+	if err := c.makeRequest(ctx, "GET", endpoint, nil, &result); err != nil {
 		return nil, fmt.Errorf("failed to get ticket %d: %w", ticketID, err)
 	}
 
@@ -208,7 +549,7 @@ func (c *Client) GetTicket(ticketID int) (*models.ConnectWiseTicket, error) {
 }
 
 // GetBoards fetches all active service boards with pagination
-func (c *Client) GetBoards() ([]models.ConnectWiseBoard, error) {
+func (c *Client) GetBoards(ctx context.Context) ([]models.ConnectWiseBoard, error) {
 	var allBoards []models.ConnectWiseBoard
 	page := 1
 	pageSize := 1000
@@ -218,7 +559,7 @@ func (c *Client) GetBoards() ([]models.ConnectWiseBoard, error) {
 		endpoint := fmt.Sprintf("/service/boards?conditions=inactiveFlag=false&page=%d&pageSize=%d", page, pageSize)
 
 		var boards []models.ConnectWiseBoard
-		if err := c.makeRequest("GET", endpoint, nil, &boards); err != nil {
+		if err := c.makeRequest(ctx, "GET", endpoint, nil, &boards); err != nil {
 			return nil, fmt.Errorf("failed to get boards (page %d): %w", page, err)
 		}
 
@@ -238,7 +579,7 @@ func (c *Client) GetBoards() ([]models.ConnectWiseBoard, error) {
 }
 
 // GetStatuses fetches all statuses for a specific board with pagination and filters out inactive ones
-func (c *Client) GetStatuses(boardID int) ([]models.ConnectWiseStatus, error) {
+func (c *Client) GetStatuses(ctx context.Context, boardID int) ([]models.ConnectWiseStatus, error) {
 	var allStatuses []models.ConnectWiseStatus
 	page := 1
 	pageSize := 1000
@@ -248,7 +589,7 @@ func (c *Client) GetStatuses(boardID int) ([]models.ConnectWiseStatus, error) {
 		endpoint := fmt.Sprintf("/service/boards/%d/statuses?page=%d&pageSize=%d", boardID, page, pageSize)
 
 		var statuses []models.ConnectWiseStatus
-		if err := c.makeRequest("GET", endpoint, nil, &statuses); err != nil {
+		if err := c.makeRequest(ctx, "GET", endpoint, nil, &statuses); err != nil {
 			return nil, fmt.Errorf("failed to get statuses for board %d (page %d): %w", boardID, page, err)
 		}
 
@@ -272,7 +613,7 @@ func (c *Client) GetStatuses(boardID int) ([]models.ConnectWiseStatus, error) {
 }
 
 // GetPriorities fetches all ticket priorities with pagination and filters out inactive ones
-func (c *Client) GetPriorities() ([]models.ConnectWisePriority, error) {
+func (c *Client) GetPriorities(ctx context.Context) ([]models.ConnectWisePriority, error) {
 	var allPriorities []models.ConnectWisePriority
 	page := 1
 	pageSize := 1000
@@ -282,7 +623,7 @@ func (c *Client) GetPriorities() ([]models.ConnectWisePriority, error) {
 		endpoint := fmt.Sprintf("/service/priorities?page=%d&pageSize=%d", page, pageSize)
 
 		var priorities []models.ConnectWisePriority
-		if err := c.makeRequest("GET", endpoint, nil, &priorities); err != nil {
+		if err := c.makeRequest(ctx, "GET", endpoint, nil, &priorities); err != nil {
 			return nil, fmt.Errorf("failed to get priorities (page %d): %w", page, err)
 		}
 
@@ -306,7 +647,7 @@ func (c *Client) GetPriorities() ([]models.ConnectWisePriority, error) {
 }
 
 // GetTypes fetches all active ticket types for a specific board with pagination
-func (c *Client) GetTypes(boardID int) ([]models.ConnectWiseType, error) {
+func (c *Client) GetTypes(ctx context.Context, boardID int) ([]models.ConnectWiseType, error) {
 	var allTypes []models.ConnectWiseType
 	page := 1
 	pageSize := 1000
@@ -316,7 +657,7 @@ func (c *Client) GetTypes(boardID int) ([]models.ConnectWiseType, error) {
 		endpoint := fmt.Sprintf("/service/boards/%d/types?conditions=inactiveFlag=false&page=%d&pageSize=%d", boardID, page, pageSize)
 
 		var types []models.ConnectWiseType
-		if err := c.makeRequest("GET", endpoint, nil, &types); err != nil {
+		if err := c.makeRequest(ctx, "GET", endpoint, nil, &types); err != nil {
 			return nil, fmt.Errorf("failed to get types for board %d (page %d): %w", boardID, page, err)
 		}
 
@@ -336,7 +677,7 @@ func (c *Client) GetTypes(boardID int) ([]models.ConnectWiseType, error) {
 }
 
 // GetMembers fetches all active members/technicians with pagination
-func (c *Client) GetMembers() ([]models.ConnectWiseMember, error) {
+func (c *Client) GetMembers(ctx context.Context) ([]models.ConnectWiseMember, error) {
 	var allMembers []models.ConnectWiseMember
 	page := 1
 	pageSize := 1000
@@ -346,7 +687,7 @@ func (c *Client) GetMembers() ([]models.ConnectWiseMember, error) {
 		endpoint := fmt.Sprintf("/system/members?conditions=inactiveFlag=false&page=%d&pageSize=%d", page, pageSize)
 
 		var members []models.ConnectWiseMember
-		if err := c.makeRequest("GET", endpoint, nil, &members); err != nil {
+		if err := c.makeRequest(ctx, "GET", endpoint, nil, &members); err != nil {
 			return nil, fmt.Errorf("failed to get members (page %d): %w", page, err)
 		}
 
@@ -365,7 +706,12 @@ func (c *Client) GetMembers() ([]models.ConnectWiseMember, error) {
 	return allMembers, nil
 }
 
-func (c *Client) makeRequest(method, endpoint string, payload interface{}, result interface{}) error {
+func (c *Client) makeRequest(ctx context.Context, method, endpoint string, payload interface{}, result interface{}) error {
+	return c.doRequest(ctx, method, c.baseURL+endpoint, endpoint, payload, result)
+}
+
+// doRequest sends an authenticated request to an absolute URL; endpoint is only used in errors
+func (c *Client) doRequest(ctx context.Context, method, requestURL, endpoint string, payload interface{}, result interface{}) error {
 	var body *bytes.Buffer
 	if payload != nil {
 		jsonData, err := json.Marshal(payload)
@@ -377,7 +723,17 @@ func (c *Client) makeRequest(method, endpoint string, payload interface{}, resul
 		body = &bytes.Buffer{}
 	}
 
-	req, err := http.NewRequest(method, c.baseURL+endpoint, body)
+	// Every call gets its own deadline on top of whatever the caller set
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	if method == http.MethodPatch {
+		// Our PATCH documents only use "replace" ops, so replaying them is safe.
+		// POSTs (e.g. ticket creation) are never marked and so never blindly retried.
+		ctx = httpclient.WithIdempotent(ctx)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, body)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -397,11 +753,7 @@ func (c *Client) makeRequest(method, endpoint string, payload interface{}, resul
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-			b, _ := io.ReadAll(resp.Body)
-			log.Printf("Go_Go_Go")
-			fmt.Println(string(b))
-		return fmt.Errorf("API request failed with status: %d", resp.StatusCode)
-
+		return httpclient.NewAPIError(method, endpoint, resp)
 	}
 
 	if result != nil {
@@ -0,0 +1,218 @@
+package connectwise_test
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"slide-cw-integration/internal/connectwise"
+	"slide-cw-integration/internal/connectwise/cwtest"
+	"slide-cw-integration/pkg/models"
+)
+
+// newBoardsServer serves two boards with different closed statuses. Help Desk has a
+// cancelled status and an inactive one sorted ahead of the status tickets should close with.
+func newBoardsServer(t *testing.T) *cwtest.Server {
+	t.Helper()
+
+	s := cwtest.NewServer()
+	t.Cleanup(s.Close)
+
+	s.AddCompanies(models.ConnectWiseClient{ID: 5, Name: "Acme Corp"})
+	s.AddBoard(models.ConnectWiseBoard{ID: 1, Name: "Help Desk"}, []models.ConnectWiseStatus{
+		{Name: "New", SortOrder: 1},
+		{Name: "Cancelled", ClosedStatus: true, SortOrder: 2},
+		{Name: "Closed (old)", ClosedStatus: true, Inactive: true, SortOrder: 3},
+		{Name: "Resolved", ClosedStatus: true, SortOrder: 4},
+		{Name: "Archived", ClosedStatus: true, SortOrder: 5},
+	}, nil)
+	s.AddBoard(models.ConnectWiseBoard{ID: 2, Name: "Backups"}, []models.ConnectWiseStatus{
+		{Name: "Open", SortOrder: 1},
+		{Name: "Completed", ClosedStatus: true, SortOrder: 2},
+	}, nil)
+	return s
+}
+
+// createTicket opens a ticket on the named board in its first status
+func createTicket(t *testing.T, client *connectwise.Client, board, status string) int {
+	t.Helper()
+
+	ticket, err := client.CreateTicketWithConfig(context.Background(), 5, "Backup failed", "Backup failed",
+		&models.TicketingConfig{BoardName: board, StatusName: status})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ticket.ID
+}
+
+func closeAndCheck(t *testing.T, s *cwtest.Server, client *connectwise.Client, ticketID int, want string) {
+	t.Helper()
+
+	if err := client.CloseTicket(context.Background(), ticketID); err != nil {
+		t.Fatalf("CloseTicket(%d): %v", ticketID, err)
+	}
+	ticket, _ := s.Ticket(ticketID)
+	if ticket.Status.Name != want || !ticket.ClosedFlag {
+		t.Errorf("ticket %d status = %q (closed %v), want %q", ticketID, ticket.Status.Name, ticket.ClosedFlag, want)
+	}
+}
+
+func TestCloseTicketPicksBoardClosedStatus(t *testing.T) {
+	s := newBoardsServer(t)
+	client := s.Client()
+
+	// The first active closed status by sort order that isn't a cancellation
+	closeAndCheck(t, s, client, createTicket(t, client, "Help Desk", "New"), "Resolved")
+	closeAndCheck(t, s, client, createTicket(t, client, "Backups", "Open"), "Completed")
+}
+
+func TestCloseTicketCachesClosedStatusPerBoard(t *testing.T) {
+	s := newBoardsServer(t)
+	client := s.Client()
+
+	for i := 0; i < 3; i++ {
+		closeAndCheck(t, s, client, createTicket(t, client, "Help Desk", "New"), "Resolved")
+		closeAndCheck(t, s, client, createTicket(t, client, "Backups", "Open"), "Completed")
+	}
+
+	for _, path := range []string{"/service/boards/1/statuses", "/service/boards/2/statuses"} {
+		if n := s.Requests(http.MethodGet, path); n != 1 {
+			t.Errorf("GET %s requested %d times, want once", path, n)
+		}
+	}
+}
+
+func TestCloseTicketUsesResolvedStatusOverride(t *testing.T) {
+	s := newBoardsServer(t)
+	client := s.Client()
+	closeAndCheck(t, s, client, createTicket(t, client, "Help Desk", "New"), "Resolved")
+
+	statuses, err := client.GetStatuses(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var archived models.ConnectWiseStatus
+	for _, status := range statuses {
+		if status.Name == "Archived" {
+			archived = status
+		}
+	}
+
+	// An admin's choice replaces the cached pick on its board only
+	client.SetResolvedStatuses([]models.BoardResolvedStatus{
+		{BoardID: 1, BoardName: "Help Desk", StatusID: archived.ID, StatusName: archived.Name},
+	})
+	closeAndCheck(t, s, client, createTicket(t, client, "Help Desk", "New"), "Archived")
+	closeAndCheck(t, s, client, createTicket(t, client, "Backups", "Open"), "Completed")
+}
+
+func TestCloseTicketForgetsRejectedStatus(t *testing.T) {
+	s := newBoardsServer(t)
+	client := s.Client()
+	closeAndCheck(t, s, client, createTicket(t, client, "Help Desk", "New"), "Resolved")
+
+	// A status renamed or deactivated since it was cached is looked up again
+	ticketID := createTicket(t, client, "Help Desk", "New")
+	s.Fail(cwtest.Failure{Method: http.MethodPatch, Path: "/service/tickets/", Status: http.StatusBadRequest,
+		Code: "InvalidObject", Message: "status is inactive"})
+	if err := client.CloseTicket(context.Background(), ticketID); err == nil {
+		t.Fatal("CloseTicket succeeded despite the rejected status")
+	}
+	closeAndCheck(t, s, client, ticketID, "Resolved")
+
+	if n := s.Requests(http.MethodGet, "/service/boards/1/statuses"); n != 2 {
+		t.Errorf("statuses requested %d times, want a second lookup after the rejection", n)
+	}
+}
+
+func TestFindTicketByNoteSearchesDescriptions(t *testing.T) {
+	s := newBoardsServer(t)
+	client := s.Client()
+	ctx := context.Background()
+	since := time.Now().Add(-time.Hour)
+
+	for i := 0; i < 5; i++ {
+		createTicket(t, client, "Help Desk", "New")
+	}
+	tagged, err := client.CreateTicketWithConfig(ctx, 5, "Backup failed", "Backup failed\n\n[slide-alert:a1]",
+		&models.TicketingConfig{BoardName: "Help Desk", StatusName: "New"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	createTicket(t, client, "Help Desk", "New")
+
+	ticket, err := client.FindTicketByNote(ctx, 5, since, "[slide-alert:a1]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket == nil || ticket.ID != tagged.ID {
+		t.Fatalf("found %+v, want ticket %d", ticket, tagged.ID)
+	}
+	if n := s.Requests(http.MethodGet, "/service/tickets"); n != 1 {
+		t.Errorf("tickets searched %d times, want a single request", n)
+	}
+	for _, ticket := range s.Tickets() {
+		if n := s.Requests(http.MethodGet, "/service/tickets/"+strconv.Itoa(ticket.ID)+"/notes"); n != 0 {
+			t.Errorf("notes of ticket %d read although the search found the tag", ticket.ID)
+		}
+	}
+}
+
+func TestFindTicketByNoteFallsBackToRecentNotes(t *testing.T) {
+	s := newBoardsServer(t)
+	client := s.Client()
+	ctx := context.Background()
+	since := time.Now().Add(-time.Hour)
+
+	tagged := createTicket(t, client, "Help Desk", "New")
+	if err := client.AddTicketNote(ctx, tagged, "[slide-alert:a1]", connectwise.NoteInternal); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 12; i++ {
+		createTicket(t, client, "Help Desk", "New")
+	}
+
+	// Only the newest tickets have their notes read, so an old note is not found
+	if ticket, err := client.FindTicketByNote(ctx, 5, since, "[slide-alert:a1]"); err != nil || ticket != nil {
+		t.Fatalf("found %+v (err %v) behind 12 newer tickets, want nothing", ticket, err)
+	}
+
+	recent := createTicket(t, client, "Help Desk", "New")
+	if err := client.AddTicketNote(ctx, recent, "[slide-alert:a2]", connectwise.NoteInternal); err != nil {
+		t.Fatal(err)
+	}
+	ticket, err := client.FindTicketByNote(ctx, 5, since, "[slide-alert:a2]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ticket == nil || ticket.ID != recent {
+		t.Fatalf("found %+v, want ticket %d", ticket, recent)
+	}
+}
+
+func TestEnsureTicketCallbacksCoversEachBoard(t *testing.T) {
+	s := newBoardsServer(t)
+	client := s.Client()
+	ctx := context.Background()
+	const url = "https://integration.example.com/api/connectwise/callback/token"
+
+	first, err := client.EnsureTicketCallbacks(ctx, url, []int{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if callbacks := s.Callbacks(); len(callbacks) != 2 || callbacks[0].ObjectID != 1 || callbacks[1].ObjectID != 2 {
+		t.Fatalf("callbacks = %+v, want one for each of boards 1 and 2", callbacks)
+	}
+
+	// Registering again keeps the existing subscriptions and drops boards no longer used
+	registered, err := client.EnsureTicketCallbacks(ctx, url, []int{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	callbacks := s.Callbacks()
+	if len(registered) != 1 || len(callbacks) != 1 || callbacks[0].ID != first[0].ID || registered[0].ID != first[0].ID {
+		t.Errorf("callbacks = %+v, want just the first one for board 1", callbacks)
+	}
+}
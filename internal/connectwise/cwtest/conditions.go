@@ -0,0 +1,308 @@
+package cwtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// condition is one "field op value" comparison from a ConnectWise conditions string
+type condition struct {
+	field string
+	op    string
+	value interface{}
+}
+
+// conditionGroup is a set of comparisons that must all hold (joined with "and")
+type conditionGroup []condition
+
+// parseConditions parses the subset of the ConnectWise conditions grammar the
+// integration uses: comparisons joined by "and"/"or" ("and" binds tighter),
+// with string, number, boolean, null and [datetime] values.
+func parseConditions(input string) ([]conditionGroup, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	var groups []conditionGroup
+	var current conditionGroup
+
+	for i := 0; i < len(tokens); {
+		if i+2 >= len(tokens) {
+			return nil, fmt.Errorf("incomplete condition near %q", strings.Join(tokens[i:], " "))
+		}
+
+		field, op, raw := tokens[i], strings.ToLower(tokens[i+1]), tokens[i+2]
+		switch op {
+		case "=", "!=", "<", "<=", ">", ">=", "contains", "like":
+		default:
+			return nil, fmt.Errorf("unsupported operator %q", op)
+		}
+
+		value, err := parseValue(raw)
+		if err != nil {
+			return nil, err
+		}
+		current = append(current, condition{field: field, op: op, value: value})
+		i += 3
+
+		if i == len(tokens) {
+			break
+		}
+
+		switch strings.ToLower(tokens[i]) {
+		case "and":
+		case "or":
+			groups = append(groups, current)
+			current = nil
+		default:
+			return nil, fmt.Errorf("expected and/or, got %q", tokens[i])
+		}
+		i++
+	}
+
+	return append(groups, current), nil
+}
+
+// tokenize splits a conditions string into fields, operators and values,
+// keeping quoted strings and bracketed dates intact
+func tokenize(input string) ([]string, error) {
+	var tokens []string
+	i := 0
+
+	for i < len(input) {
+		ch := input[i]
+		switch {
+		case ch == ' ' || ch == '\t':
+			i++
+		case ch == '"' || ch == '\'':
+			end := i + 1
+			for end < len(input) && input[end] != ch {
+				if input[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(input) {
+				return nil, fmt.Errorf("unterminated string in conditions")
+			}
+			tokens = append(tokens, input[i:end+1])
+			i = end + 1
+		case ch == '[':
+			end := strings.IndexByte(input[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated date in conditions")
+			}
+			tokens = append(tokens, input[i:i+end+1])
+			i += end + 1
+		case ch == '=' || ch == '!' || ch == '<' || ch == '>':
+			end := i + 1
+			if end < len(input) && input[end] == '=' {
+				end++
+			}
+			tokens = append(tokens, input[i:end])
+			i = end
+		default:
+			end := i
+			for end < len(input) && !strings.ContainsRune(" \t=!<>", rune(input[end])) {
+				end++
+			}
+			tokens = append(tokens, input[i:end])
+			i = end
+		}
+	}
+
+	return tokens, nil
+}
+
+func parseValue(raw string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`) || strings.HasPrefix(raw, `'`):
+		unquoted := raw[1 : len(raw)-1]
+		return strings.ReplaceAll(unquoted, `\`+raw[:1], raw[:1]), nil
+	case strings.HasPrefix(raw, "["):
+		t, err := time.Parse(time.RFC3339, raw[1:len(raw)-1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid date %s: %w", raw, err)
+		}
+		return t, nil
+	case strings.EqualFold(raw, "true"):
+		return true, nil
+	case strings.EqualFold(raw, "false"):
+		return false, nil
+	case strings.EqualFold(raw, "null"):
+		return nil, nil
+	}
+
+	n, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", raw)
+	}
+	return n, nil
+}
+
+// matchConditions reports whether item (any JSON-serialisable value) satisfies groups
+func matchConditions(item interface{}, groups []conditionGroup) bool {
+	if len(groups) == 0 {
+		return true
+	}
+
+	raw, err := json.Marshal(item)
+	if err != nil {
+		return false
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return false
+	}
+
+	for _, group := range groups {
+		matched := true
+		for _, cond := range group {
+			if !cond.matches(doc) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c condition) matches(doc map[string]interface{}) bool {
+	actual, found := lookup(doc, c.field)
+	if !found {
+		// ConnectWise keeps audit dates under _info
+		actual, found = lookup(doc, "_info/"+c.field)
+	}
+
+	if c.value == nil {
+		isNull := !found || actual == nil
+		if c.op == "!=" {
+			return !isNull
+		}
+		return isNull
+	}
+
+	// Missing fields behave like their zero value, as they do when omitted from JSON
+	if !found || actual == nil {
+		switch c.value.(type) {
+		case bool:
+			actual = false
+		case float64:
+			actual = float64(0)
+		case string:
+			actual = ""
+		default:
+			return c.op == "!="
+		}
+	}
+
+	switch want := c.value.(type) {
+	case bool:
+		got, ok := actual.(bool)
+		if !ok {
+			return false
+		}
+		return compareOrdered(boolInt(got), boolInt(want), c.op)
+	case float64:
+		got, ok := actual.(float64)
+		if !ok {
+			return false
+		}
+		return compareOrdered(got, want, c.op)
+	case time.Time:
+		text, ok := actual.(string)
+		if !ok {
+			return false
+		}
+		got, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			return false
+		}
+		return compareOrdered(got.Unix(), want.Unix(), c.op)
+	case string:
+		got := fmt.Sprint(actual)
+		switch c.op {
+		case "contains":
+			return strings.Contains(strings.ToLower(got), strings.ToLower(want))
+		case "like":
+			return likeMatch(strings.ToLower(got), strings.ToLower(want))
+		}
+		// String equality is case-insensitive in ConnectWise
+		return compareOrdered(strings.ToLower(got), strings.ToLower(want), c.op)
+	}
+
+	return false
+}
+
+func lookup(doc map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = doc
+	for _, part := range strings.Split(path, "/") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = obj[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func compareOrdered[T int | int64 | float64 | string](got, want T, op string) bool {
+	switch op {
+	case "=":
+		return got == want
+	case "!=":
+		return got != want
+	case "<":
+		return got < want
+	case "<=":
+		return got <= want
+	case ">":
+		return got > want
+	case ">=":
+		return got >= want
+	}
+	return false
+}
+
+// likeMatch implements the % wildcard used by ConnectWise "like"
+func likeMatch(s, pattern string) bool {
+	parts := strings.Split(pattern, "%")
+	if len(parts) == 1 {
+		return s == pattern
+	}
+
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(s, part)
+		if idx < 0 {
+			return false
+		}
+		s = s[idx+len(part):]
+	}
+
+	return strings.HasSuffix(s, parts[len(parts)-1])
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
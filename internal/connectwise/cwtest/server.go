@@ -0,0 +1,1058 @@
+// Package cwtest provides an in-process fake of the ConnectWise Manage REST API
+// with enough fidelity (pagination, conditions, board-specific statuses) to
+// exercise the integration end to end without a real ConnectWise instance.
+package cwtest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"slide-cw-integration/internal/connectwise"
+	"slide-cw-integration/pkg/models"
+)
+
+// Credentials the fake server accepts
+const (
+	CompanyID  = "cwtest"
+	PublicKey  = "cwtest-public"
+	PrivateKey = "cwtest-private"
+	ClientID   = "cwtest-client-id"
+
+	// SigningKey signs callback deliveries, as served from the payload's key_url
+	SigningKey = "cwtest-signing-key"
+)
+
+// Ref is a ConnectWise object reference such as {"id": 1, "name": "New"}
+type Ref struct {
+	ID         int    `json:"id,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Identifier string `json:"identifier,omitempty"`
+}
+
+// Info carries the audit fields ConnectWise nests under _info
+type Info struct {
+	DateEntered time.Time `json:"dateEntered"`
+	LastUpdated time.Time `json:"lastUpdated"`
+}
+
+// Ticket is the fake's view of a service ticket
+type Ticket struct {
+	ID                 int    `json:"id"`
+	Summary            string `json:"summary"`
+	Board              Ref    `json:"board"`
+	Status             Ref    `json:"status"`
+	Company            Ref    `json:"company"`
+	Priority           *Ref   `json:"priority,omitempty"`
+	Type               *Ref   `json:"type,omitempty"`
+	SubType            *Ref   `json:"subType,omitempty"`
+	Item               *Ref   `json:"item,omitempty"`
+	Owner              *Ref   `json:"owner,omitempty"`
+	Resources          string `json:"resources,omitempty"`
+	InitialDescription string `json:"initialDescription,omitempty"`
+	ClosedFlag         bool   `json:"closedFlag"`
+	Info               Info   `json:"_info"`
+}
+
+// TicketPatch records a PATCH received for a ticket
+type TicketPatch struct {
+	TicketID   int
+	Ops        []connectwise.PatchDoc
+	Applied    bool
+	ReceivedAt time.Time
+}
+
+// Note is a ticket note
+type Note struct {
+	ID                    int       `json:"id"`
+	TicketID              int       `json:"ticketId"`
+	Text                  string    `json:"text"`
+	DetailDescriptionFlag bool      `json:"detailDescriptionFlag"`
+	InternalAnalysisFlag  bool      `json:"internalAnalysisFlag"`
+	ResolutionFlag        bool      `json:"resolutionFlag"`
+	DateCreated           time.Time `json:"dateCreated"`
+}
+
+// ScheduleEntry is a member scheduled on (or added as a resource to) a ticket
+type ScheduleEntry struct {
+	ID        int        `json:"id"`
+	ObjectID  int        `json:"objectId"`
+	Member    Ref        `json:"member"`
+	Type      Ref        `json:"type"`
+	DateStart *time.Time `json:"dateStart,omitempty"`
+	DateEnd   *time.Time `json:"dateEnd,omitempty"`
+}
+
+// Failure scripts an error response for matching requests
+type Failure struct {
+	// Method and Path select the requests to fail; Path matches as a prefix.
+	// An empty Method matches any method.
+	Method string
+	Path   string
+
+	Status     int
+	Code       string
+	Message    string
+	RetryAfter string
+
+	// Times is how many matching requests fail before the route recovers
+	Times int
+}
+
+type board struct {
+	info     models.ConnectWiseBoard
+	statuses []models.ConnectWiseStatus
+	types    []models.ConnectWiseType
+}
+
+// Server is a fake ConnectWise Manage API
+type Server struct {
+	URL string
+
+	srv *httptest.Server
+
+	mu           sync.Mutex
+	companies    []models.ConnectWiseClient
+	boards       []*board
+	priorities   []models.ConnectWisePriority
+	members      []models.ConnectWiseMember
+	tickets      []*Ticket
+	patches      []TicketPatch
+	schedule     []ScheduleEntry
+	notes        []Note
+	callbacks    []models.ConnectWiseCallback
+	failures     []*Failure
+	requests     map[string]int
+	nextTicketID int
+	nextStatusID int
+}
+
+// NewServer starts a fake ConnectWise API. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		requests:     make(map[string]int),
+		nextTicketID: 1000,
+		nextStatusID: 1,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /company/companies", s.handleCompanies)
+	mux.HandleFunc("GET /service/boards", s.handleBoards)
+	mux.HandleFunc("GET /service/boards/{id}/statuses", s.handleStatuses)
+	mux.HandleFunc("GET /service/boards/{id}/types", s.handleTypes)
+	mux.HandleFunc("GET /service/priorities", s.handlePriorities)
+	mux.HandleFunc("GET /system/members", s.handleMembers)
+	mux.HandleFunc("GET /service/tickets", s.handleListTickets)
+	mux.HandleFunc("POST /service/tickets", s.handleCreateTicket)
+	mux.HandleFunc("GET /service/tickets/{id}", s.handleGetTicket)
+	mux.HandleFunc("PATCH /service/tickets/{id}", s.handlePatchTicket)
+	mux.HandleFunc("GET /service/tickets/{id}/notes", s.handleListNotes)
+	mux.HandleFunc("POST /service/tickets/{id}/notes", s.handleCreateNote)
+	mux.HandleFunc("POST /schedule/entries", s.handleCreateScheduleEntry)
+	mux.HandleFunc("GET /system/callbacks", s.handleListCallbacks)
+	mux.HandleFunc("POST /system/callbacks", s.handleCreateCallback)
+	mux.HandleFunc("DELETE /system/callbacks/{id}", s.handleDeleteCallback)
+	mux.HandleFunc("GET /callbackkey", s.handleSigningKey)
+
+	s.srv = httptest.NewServer(s.middleware(mux))
+	s.URL = s.srv.URL
+
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client returns a connectwise.Client pointed at the fake server
+func (s *Server) Client() *connectwise.Client {
+	return connectwise.NewClient(s.URL, CompanyID, PublicKey, PrivateKey, ClientID)
+}
+
+// AddCompanies seeds companies
+func (s *Server) AddCompanies(companies ...models.ConnectWiseClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.companies = append(s.companies, companies...)
+}
+
+// AddBoard seeds a service board with its statuses and types. Status and type
+// IDs are assigned when zero, and BoardID is filled in on both.
+func (s *Server) AddBoard(info models.ConnectWiseBoard, statuses []models.ConnectWiseStatus, types []models.ConnectWiseType) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := &board{info: info}
+	for i, status := range statuses {
+		if status.ID == 0 {
+			status.ID = s.nextStatusID
+			s.nextStatusID++
+		}
+		status.BoardID = info.ID
+		if status.SortOrder == 0 {
+			status.SortOrder = i
+		}
+		b.statuses = append(b.statuses, status)
+	}
+	for i, t := range types {
+		if t.ID == 0 {
+			t.ID = i + 1
+		}
+		t.BoardID = info.ID
+		b.types = append(b.types, t)
+	}
+
+	s.boards = append(s.boards, b)
+}
+
+// AddPriorities seeds ticket priorities
+func (s *Server) AddPriorities(priorities ...models.ConnectWisePriority) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.priorities = append(s.priorities, priorities...)
+}
+
+// AddMembers seeds members (technicians)
+func (s *Server) AddMembers(members ...models.ConnectWiseMember) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.members = append(s.members, members...)
+}
+
+// Ticket returns a copy of a ticket's current state
+func (s *Server) Ticket(ticketID int) (Ticket, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t := s.findTicket(ticketID); t != nil {
+		return *t, true
+	}
+	return Ticket{}, false
+}
+
+// Tickets returns copies of every ticket, oldest first
+func (s *Server) Tickets() []Ticket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tickets := make([]Ticket, 0, len(s.tickets))
+	for _, t := range s.tickets {
+		tickets = append(tickets, *t)
+	}
+	return tickets
+}
+
+// Patches returns every ticket PATCH received so far, including rejected ones
+func (s *Server) Patches() []TicketPatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]TicketPatch(nil), s.patches...)
+}
+
+// Notes returns the notes posted to a ticket, oldest first
+func (s *Server) Notes(ticketID int) []Note {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ticketNotes(ticketID)
+}
+
+// Callbacks returns the registered callback subscriptions
+func (s *Server) Callbacks() []models.ConnectWiseCallback {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.ConnectWiseCallback(nil), s.callbacks...)
+}
+
+// DeliverTicketCallback POSTs a signed "updated" callback for ticketID to every active ticket
+// subscription covering its board, as ConnectWise does after a ticket changes. It returns
+// how many deliveries were accepted.
+func (s *Server) DeliverTicketCallback(ticketID int) (int, error) {
+	s.mu.Lock()
+	t := s.findTicket(ticketID)
+	if t == nil {
+		s.mu.Unlock()
+		return 0, fmt.Errorf("ticket %d not found", ticketID)
+	}
+	entity, _ := json.Marshal(t)
+	boardID := t.Board.ID
+
+	var urls []string
+	for _, callback := range s.callbacks {
+		if callback.InactiveFlag || !strings.EqualFold(callback.Type, "ticket") {
+			continue
+		}
+		if strings.EqualFold(callback.Level, "board") && callback.ObjectID != boardID {
+			continue
+		}
+		urls = append(urls, callback.URL)
+	}
+	s.mu.Unlock()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"MessageId": fmt.Sprintf("cwtest-%d-%d", ticketID, time.Now().UnixNano()),
+		"Action":    "updated",
+		"Type":      "ticket",
+		"ID":        ticketID,
+		"Entity":    string(entity),
+		"Metadata":  map[string]string{"key_url": s.URL + "/callbackkey"},
+	})
+
+	mac := hmac.New(sha256.New, []byte(SigningKey))
+	mac.Write(body)
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	accepted := 0
+	for _, url := range urls {
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return accepted, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-content-signature", signature)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return accepted, err
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			accepted++
+		}
+	}
+
+	return accepted, nil
+}
+
+// ScheduleEntries returns every schedule entry created so far
+func (s *Server) ScheduleEntries() []ScheduleEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ScheduleEntry(nil), s.schedule...)
+}
+
+// SetTicketStatus moves a ticket to statusName as a technician would in the UI.
+// The status must exist on the ticket's board.
+func (s *Server) SetTicketStatus(ticketID int, statusName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.findTicket(ticketID)
+	if t == nil {
+		return fmt.Errorf("ticket %d not found", ticketID)
+	}
+
+	status, ok := s.boardStatus(t.Board.ID, Ref{Name: statusName})
+	if !ok {
+		return fmt.Errorf("status %q does not exist on board %q", statusName, t.Board.Name)
+	}
+
+	s.applyStatus(t, status)
+	return nil
+}
+
+// DeleteTicket removes a ticket so later lookups 404
+func (s *Server) DeleteTicket(ticketID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, t := range s.tickets {
+		if t.ID == ticketID {
+			s.tickets = append(s.tickets[:i], s.tickets[i+1:]...)
+			return
+		}
+	}
+}
+
+// Fail scripts a failure for matching requests
+func (s *Server) Fail(failure Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if failure.Times <= 0 {
+		failure.Times = 1
+	}
+	s.failures = append(s.failures, &failure)
+}
+
+// Requests returns how many requests were received for method and path (without query)
+func (s *Server) Requests(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[method+" "+path]
+}
+
+// middleware checks auth, counts requests and applies scripted failures
+func (s *Server) middleware(next http.Handler) http.Handler {
+	expectedAuth := "Basic " + base64.StdEncoding.EncodeToString(
+		[]byte(CompanyID+"+"+PublicKey+":"+PrivateKey))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.Method+" "+r.URL.Path]++
+		failure := s.takeFailure(r)
+		s.mu.Unlock()
+
+		if failure != nil {
+			if failure.RetryAfter != "" {
+				w.Header().Set("Retry-After", failure.RetryAfter)
+			}
+			writeError(w, failure.Status, failure.Code, failure.Message)
+			return
+		}
+
+		if r.Header.Get("Authorization") != expectedAuth || r.Header.Get("clientId") != ClientID {
+			writeError(w, http.StatusUnauthorized, "Unauthorized", "invalid credentials")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// takeFailure consumes the first scripted failure matching r. Callers hold s.mu.
+func (s *Server) takeFailure(r *http.Request) *Failure {
+	for i, failure := range s.failures {
+		if failure.Method != "" && failure.Method != r.Method {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, failure.Path) {
+			continue
+		}
+
+		failure.Times--
+		if failure.Times <= 0 {
+			s.failures = append(s.failures[:i], s.failures[i+1:]...)
+		}
+		return failure
+	}
+	return nil
+}
+
+func (s *Server) handleCompanies(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	companies := append([]models.ConnectWiseClient(nil), s.companies...)
+	s.mu.Unlock()
+
+	if r.URL.Query().Get("orderBy") == "name" {
+		sort.SliceStable(companies, func(i, j int) bool { return companies[i].Name < companies[j].Name })
+	}
+
+	// The company model has no deletedFlag, so expose it for the client's filter
+	type company struct {
+		models.ConnectWiseClient
+		DeletedFlag bool `json:"deletedFlag"`
+	}
+	items := make([]company, len(companies))
+	for i, c := range companies {
+		items[i] = company{ConnectWiseClient: c}
+	}
+
+	writeList(w, r, items)
+}
+
+func (s *Server) handleBoards(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	boards := make([]models.ConnectWiseBoard, len(s.boards))
+	for i, b := range s.boards {
+		boards[i] = b.info
+	}
+	s.mu.Unlock()
+
+	writeList(w, r, boards)
+}
+
+func (s *Server) handleStatuses(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	b := s.boardByPath(r)
+	var statuses []models.ConnectWiseStatus
+	if b != nil {
+		statuses = append(statuses, b.statuses...)
+	}
+	s.mu.Unlock()
+
+	if b == nil {
+		writeError(w, http.StatusNotFound, "NotFound", "board not found")
+		return
+	}
+	writeList(w, r, statuses)
+}
+
+func (s *Server) handleTypes(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	b := s.boardByPath(r)
+	var types []models.ConnectWiseType
+	if b != nil {
+		types = append(types, b.types...)
+	}
+	s.mu.Unlock()
+
+	if b == nil {
+		writeError(w, http.StatusNotFound, "NotFound", "board not found")
+		return
+	}
+	writeList(w, r, types)
+}
+
+func (s *Server) handlePriorities(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	priorities := append([]models.ConnectWisePriority(nil), s.priorities...)
+	s.mu.Unlock()
+
+	writeList(w, r, priorities)
+}
+
+func (s *Server) handleMembers(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	members := append([]models.ConnectWiseMember(nil), s.members...)
+	s.mu.Unlock()
+
+	writeList(w, r, members)
+}
+
+func (s *Server) handleListTickets(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	tickets := make([]Ticket, len(s.tickets))
+	for i, t := range s.tickets {
+		tickets[i] = *t
+	}
+	s.mu.Unlock()
+
+	writeList(w, r, tickets)
+}
+
+func (s *Server) handleGetTicket(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(r.PathValue("id"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.findTicket(id)
+	if t == nil {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("Ticket with id %d not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) handleCreateTicket(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Ticket
+		Board    Ref  `json:"board"`
+		Status   *Ref `json:"status"`
+		Priority *Ref `json:"priority"`
+		Type     *Ref `json:"type"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidObject", "invalid JSON body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var fieldErrors []map[string]string
+	invalid := func(field, message string) {
+		fieldErrors = append(fieldErrors, map[string]string{
+			"code": "InvalidField", "message": message, "resource": "ticket", "field": field,
+		})
+	}
+
+	if strings.TrimSpace(req.Summary) == "" {
+		invalid("summary", "Summary is required")
+	}
+
+	company, ok := s.findCompany(req.Company.ID)
+	if !ok {
+		invalid("company", fmt.Sprintf("Company with id %d not found", req.Company.ID))
+	}
+
+	b := s.findBoard(req.Board)
+	if b == nil {
+		invalid("board", fmt.Sprintf("Board %q not found", req.Board.Name))
+	}
+
+	t := req.Ticket
+	if b != nil {
+		t.Board = Ref{ID: b.info.ID, Name: b.info.Name}
+
+		// Status names are board specific - this is what trips up name guessing
+		if req.Status != nil && (req.Status.ID != 0 || req.Status.Name != "") {
+			status, ok := s.boardStatus(b.info.ID, *req.Status)
+			if !ok {
+				invalid("status", fmt.Sprintf("Status %q is not valid for board %q", req.Status.Name, b.info.Name))
+			} else {
+				s.applyStatus(&t, status)
+			}
+		} else if len(b.statuses) > 0 {
+			s.applyStatus(&t, b.statuses[0])
+		}
+
+		if req.Type != nil && req.Type.Name != "" {
+			found := false
+			for _, typ := range b.types {
+				if strings.EqualFold(typ.Name, req.Type.Name) {
+					t.Type = &Ref{ID: typ.ID, Name: typ.Name}
+					found = true
+				}
+			}
+			if !found {
+				invalid("type", fmt.Sprintf("Type %q is not valid for board %q", req.Type.Name, b.info.Name))
+			}
+		}
+	}
+
+	if req.Owner != nil {
+		member, ok := s.findMember(*req.Owner)
+		if !ok {
+			invalid("owner", fmt.Sprintf("Member %d not found", req.Owner.ID))
+		} else {
+			t.Owner = &Ref{ID: member.ID, Identifier: member.Identifier}
+		}
+	}
+
+	if req.Priority != nil && req.Priority.Name != "" {
+		found := false
+		for _, p := range s.priorities {
+			if strings.EqualFold(p.Name, req.Priority.Name) {
+				t.Priority = &Ref{ID: p.ID, Name: p.Name}
+				found = true
+			}
+		}
+		if !found {
+			invalid("priority", fmt.Sprintf("Priority %q not found", req.Priority.Name))
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"code":    "InvalidObject",
+			"message": "ticket object is invalid",
+			"errors":  fieldErrors,
+		})
+		return
+	}
+
+	t.ID = s.nextTicketID
+	s.nextTicketID++
+	t.Company = Ref{ID: company.ID, Name: company.Name}
+	now := time.Now().UTC()
+	t.Info = Info{DateEntered: now, LastUpdated: now}
+
+	s.tickets = append(s.tickets, &t)
+
+	// ConnectWise files the initial description as the ticket's first note
+	if t.InitialDescription != "" {
+		s.notes = append(s.notes, Note{
+			ID:                    len(s.notes) + 1,
+			TicketID:              t.ID,
+			Text:                  t.InitialDescription,
+			DetailDescriptionFlag: true,
+			DateCreated:           now,
+		})
+	}
+
+	writeJSON(w, http.StatusCreated, t)
+}
+
+func (s *Server) handlePatchTicket(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(r.PathValue("id"))
+
+	var ops []connectwise.PatchDoc
+	if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidObject", "PATCH body must be an array of operations")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record := TicketPatch{TicketID: id, Ops: ops, ReceivedAt: time.Now()}
+	defer func() { s.patches = append(s.patches, record) }()
+
+	t := s.findTicket(id)
+	if t == nil {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("Ticket with id %d not found", id))
+		return
+	}
+
+	// Validate every op against a copy so a bad op leaves the ticket untouched
+	updated := *t
+	for _, op := range ops {
+		if op.Op != "replace" {
+			writeError(w, http.StatusBadRequest, "InvalidObject", fmt.Sprintf("unsupported op %q", op.Op))
+			return
+		}
+
+		path := strings.TrimPrefix(op.Path, "/")
+		if path == "summary" {
+			summary, ok := op.Value.(string)
+			if !ok {
+				writeError(w, http.StatusBadRequest, "InvalidObject", "summary must be a string")
+				return
+			}
+			updated.Summary = summary
+			continue
+		}
+
+		ref, err := decodeRef(op.Value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidObject", err.Error())
+			return
+		}
+
+		switch path {
+		case "status":
+			status, ok := s.boardStatus(updated.Board.ID, ref)
+			if !ok {
+				writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+					"code":    "InvalidObject",
+					"message": "ticket object is invalid",
+					"errors": []map[string]string{{
+						"code": "InvalidField", "resource": "ticket", "field": "status",
+						"message": fmt.Sprintf("Status %q is not valid for board %q", ref.Name, updated.Board.Name),
+					}},
+				})
+				return
+			}
+			s.applyStatus(&updated, status)
+		case "priority":
+			updated.Priority = &ref
+		case "type":
+			updated.Type = &ref
+		case "subType":
+			updated.SubType = &ref
+		case "item":
+			updated.Item = &ref
+		case "owner":
+			updated.Owner = &ref
+		default:
+			writeError(w, http.StatusBadRequest, "InvalidObject", fmt.Sprintf("unsupported path %q", op.Path))
+			return
+		}
+	}
+
+	updated.Info.LastUpdated = time.Now().UTC()
+	*t = updated
+	record.Applied = true
+
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) handleListNotes(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(r.PathValue("id"))
+
+	s.mu.Lock()
+	found := s.findTicket(id) != nil
+	notes := s.ticketNotes(id)
+	s.mu.Unlock()
+
+	if !found {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("Ticket with id %d not found", id))
+		return
+	}
+	writeList(w, r, notes)
+}
+
+func (s *Server) handleCreateNote(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(r.PathValue("id"))
+
+	var note Note
+	if err := json.NewDecoder(r.Body).Decode(&note); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidObject", "invalid JSON body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.findTicket(id) == nil {
+		writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("Ticket with id %d not found", id))
+		return
+	}
+
+	if strings.TrimSpace(note.Text) == "" {
+		writeError(w, http.StatusBadRequest, "InvalidObject", "note text is required")
+		return
+	}
+
+	// ConnectWise rejects notes that aren't filed under any section
+	if !note.DetailDescriptionFlag && !note.InternalAnalysisFlag && !note.ResolutionFlag {
+		writeError(w, http.StatusBadRequest, "InvalidObject", "one of detailDescriptionFlag, internalAnalysisFlag or resolutionFlag must be set")
+		return
+	}
+
+	note.ID = len(s.notes) + 1
+	note.TicketID = id
+	note.DateCreated = time.Now().UTC()
+	s.notes = append(s.notes, note)
+
+	writeJSON(w, http.StatusCreated, note)
+}
+
+func (s *Server) handleCreateScheduleEntry(w http.ResponseWriter, r *http.Request) {
+	var entry ScheduleEntry
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidObject", "invalid JSON body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry.Type.Identifier != "S" {
+		writeError(w, http.StatusBadRequest, "InvalidObject", fmt.Sprintf("unsupported schedule type %q", entry.Type.Identifier))
+		return
+	}
+
+	t := s.findTicket(entry.ObjectID)
+	if t == nil {
+		writeError(w, http.StatusBadRequest, "InvalidObject", fmt.Sprintf("Ticket with id %d not found", entry.ObjectID))
+		return
+	}
+
+	member, ok := s.findMember(entry.Member)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "InvalidObject", fmt.Sprintf("Member %d not found", entry.Member.ID))
+		return
+	}
+
+	if entry.DateStart != nil && entry.DateEnd != nil && !entry.DateEnd.After(*entry.DateStart) {
+		writeError(w, http.StatusBadRequest, "InvalidObject", "dateEnd must be after dateStart")
+		return
+	}
+
+	entry.ID = len(s.schedule) + 1
+	entry.Member = Ref{ID: member.ID, Identifier: member.Identifier}
+	s.schedule = append(s.schedule, entry)
+
+	// ConnectWise lists scheduled members on the ticket as a comma separated string
+	if t.Resources == "" {
+		t.Resources = member.Identifier
+	} else if !strings.Contains(", "+t.Resources+",", ", "+member.Identifier+",") {
+		t.Resources += ", " + member.Identifier
+	}
+
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+func (s *Server) handleListCallbacks(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	callbacks := append([]models.ConnectWiseCallback(nil), s.callbacks...)
+	s.mu.Unlock()
+
+	writeList(w, r, callbacks)
+}
+
+func (s *Server) handleCreateCallback(w http.ResponseWriter, r *http.Request) {
+	var callback models.ConnectWiseCallback
+	if err := json.NewDecoder(r.Body).Decode(&callback); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidObject", "invalid JSON body")
+		return
+	}
+
+	if callback.URL == "" || callback.Type == "" || callback.Level == "" {
+		writeError(w, http.StatusBadRequest, "InvalidObject", "url, type and level are required")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	callback.ID = len(s.callbacks) + 1
+	for _, existing := range s.callbacks {
+		if existing.ID >= callback.ID {
+			callback.ID = existing.ID + 1
+		}
+	}
+	s.callbacks = append(s.callbacks, callback)
+
+	writeJSON(w, http.StatusCreated, callback)
+}
+
+func (s *Server) handleDeleteCallback(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(r.PathValue("id"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, callback := range s.callbacks {
+		if callback.ID == id {
+			s.callbacks = append(s.callbacks[:i], s.callbacks[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "NotFound", fmt.Sprintf("Callback with id %d not found", id))
+}
+
+func (s *Server) handleSigningKey(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"SigningKey": SigningKey})
+}
+
+// ticketNotes returns a ticket's notes. Callers hold s.mu.
+func (s *Server) ticketNotes(ticketID int) []Note {
+	notes := []Note{}
+	for _, note := range s.notes {
+		if note.TicketID == ticketID {
+			notes = append(notes, note)
+		}
+	}
+	return notes
+}
+
+// findMember resolves a member reference by ID or identifier. Callers hold s.mu.
+func (s *Server) findMember(ref Ref) (models.ConnectWiseMember, bool) {
+	for _, m := range s.members {
+		if (ref.ID != 0 && m.ID == ref.ID) || (ref.ID == 0 && ref.Identifier != "" && strings.EqualFold(m.Identifier, ref.Identifier)) {
+			return m, true
+		}
+	}
+	return models.ConnectWiseMember{}, false
+}
+
+// findTicket looks up a ticket by ID. Callers hold s.mu.
+func (s *Server) findTicket(id int) *Ticket {
+	for _, t := range s.tickets {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
+
+// findCompany looks up a company by ID. Callers hold s.mu.
+func (s *Server) findCompany(id int) (models.ConnectWiseClient, bool) {
+	for _, c := range s.companies {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return models.ConnectWiseClient{}, false
+}
+
+// findBoard resolves a board reference by ID or name. Callers hold s.mu.
+func (s *Server) findBoard(ref Ref) *board {
+	for _, b := range s.boards {
+		if (ref.ID != 0 && b.info.ID == ref.ID) || (ref.ID == 0 && strings.EqualFold(b.info.Name, ref.Name)) {
+			return b
+		}
+	}
+	return nil
+}
+
+// boardByPath resolves the {id} path segment to a board. Callers hold s.mu.
+func (s *Server) boardByPath(r *http.Request) *board {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return nil
+	}
+	return s.findBoard(Ref{ID: id})
+}
+
+// boardStatus resolves a status reference against a board's own statuses. Callers hold s.mu.
+func (s *Server) boardStatus(boardID int, ref Ref) (models.ConnectWiseStatus, bool) {
+	b := s.findBoard(Ref{ID: boardID})
+	if b == nil {
+		return models.ConnectWiseStatus{}, false
+	}
+
+	for _, status := range b.statuses {
+		if status.Inactive {
+			continue
+		}
+		if (ref.ID != 0 && status.ID == ref.ID) || (ref.ID == 0 && strings.EqualFold(status.Name, ref.Name)) {
+			return status, true
+		}
+	}
+	return models.ConnectWiseStatus{}, false
+}
+
+func (s *Server) applyStatus(t *Ticket, status models.ConnectWiseStatus) {
+	t.Status = Ref{ID: status.ID, Name: status.Name}
+	t.ClosedFlag = status.ClosedStatus
+}
+
+func decodeRef(value interface{}) (Ref, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return Ref{}, err
+	}
+
+	var ref Ref
+	if err := json.Unmarshal(raw, &ref); err != nil {
+		return Ref{}, fmt.Errorf("value must be a reference object: %w", err)
+	}
+	if ref.ID == 0 && ref.Name == "" && ref.Identifier == "" {
+		return Ref{}, fmt.Errorf("reference must have an id, name or identifier")
+	}
+	return ref, nil
+}
+
+// writeList applies conditions, orderBy and page/pageSize the way ConnectWise does and
+// writes a JSON array. Items are kept in id order, so only "id desc" needs reordering.
+func writeList[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	query := r.URL.Query()
+
+	groups, err := parseConditions(query.Get("conditions"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidConditions", err.Error())
+		return
+	}
+
+	filtered := []T{}
+	for _, item := range items {
+		if matchConditions(item, groups) {
+			filtered = append(filtered, item)
+		}
+	}
+	if strings.EqualFold(query.Get("orderBy"), "id desc") {
+		for i, j := 0, len(filtered)-1; i < j; i, j = i+1, j-1 {
+			filtered[i], filtered[j] = filtered[j], filtered[i]
+		}
+	}
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(query.Get("pageSize"))
+	if pageSize < 1 {
+		pageSize = 25
+	}
+	if pageSize > 1000 {
+		pageSize = 1000
+	}
+
+	start := (page - 1) * pageSize
+	if start > len(filtered) {
+		start = len(filtered)
+	}
+	end := start + pageSize
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+
+	writeJSON(w, http.StatusOK, filtered[start:end])
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{"code": code, "message": message})
+}
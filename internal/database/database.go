@@ -1,14 +1,19 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
+	"time"
 
 	_ "modernc.org/sqlite" // Pure Go SQLite driver - I struggled with this - for some stupid reason....
 	"slide-cw-integration/pkg/models"
 )
 
+// queryTimeout bounds every individual database call
+const queryTimeout = 10 * time.Second
+
 type DB struct {
 	conn *sql.DB
 }
@@ -19,6 +24,11 @@ func Initialize() (*DB, error) {
 		dbPath = "./slide_cw_integration.db"
 	}
 
+	return Open(dbPath)
+}
+
+// Open opens (creating if needed) the SQLite database at dbPath and ensures the schema exists
+func Open(dbPath string) (*DB, error) {
 	conn, err := sql.Open("sqlite", dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -41,6 +51,11 @@ func (db *DB) GetConn() *sql.DB {
 	return db.conn
 }
 
+// withTimeout derives a per-call deadline so a locked database can't hang a caller
+func withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, queryTimeout)
+}
+
 func (db *DB) createTables() error {
 	queries := []string{
 		`CREATE TABLE IF NOT EXISTS client_mappings (
@@ -76,6 +91,118 @@ func (db *DB) createTables() error {
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 		)`,
+		`CREATE TABLE IF NOT EXISTS app_settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS board_resolved_statuses (
+			board_id INTEGER PRIMARY KEY,
+			board_name TEXT NOT NULL,
+			status_id INTEGER NOT NULL,
+			status_name TEXT NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS work_queue (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			action TEXT NOT NULL,
+			alert_id TEXT NOT NULL,
+			ticket_id INTEGER NOT NULL DEFAULT 0,
+			payload TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			next_attempt_at DATETIME NOT NULL,
+			last_error TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE (action, alert_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS leases (
+			name TEXT PRIMARY KEY,
+			holder TEXT NOT NULL,
+			acquired_at DATETIME NOT NULL,
+			renewed_at DATETIME NOT NULL,
+			expires_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS pending_tickets (
+			alert_id TEXT PRIMARY KEY,
+			company_id INTEGER NOT NULL,
+			agent_id TEXT NOT NULL DEFAULT '',
+			alert_type TEXT NOT NULL DEFAULT '',
+			correlation_key TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS routing_rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			position INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			action TEXT NOT NULL DEFAULT 'route',
+			match_alert_type TEXT NOT NULL DEFAULT '',
+			match_client_id TEXT NOT NULL DEFAULT '',
+			match_company_id INTEGER NOT NULL DEFAULT 0,
+			match_device TEXT NOT NULL DEFAULT '',
+			match_agent_name TEXT NOT NULL DEFAULT '',
+			match_message TEXT NOT NULL DEFAULT '',
+			board_id INTEGER NOT NULL DEFAULT 0,
+			board_name TEXT NOT NULL DEFAULT '',
+			status_name TEXT NOT NULL DEFAULT '',
+			priority_name TEXT NOT NULL DEFAULT '',
+			type_name TEXT NOT NULL DEFAULT '',
+			subtype_name TEXT NOT NULL DEFAULT '',
+			item_name TEXT NOT NULL DEFAULT '',
+			owner_id INTEGER,
+			owner_name TEXT NOT NULL DEFAULT '',
+			summary_template TEXT NOT NULL DEFAULT '',
+			description_template TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS maintenance_windows (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			scope TEXT NOT NULL,
+			scope_id TEXT NOT NULL,
+			timezone TEXT NOT NULL,
+			starts_at TEXT NOT NULL,
+			duration_minutes INTEGER NOT NULL,
+			recurrence TEXT NOT NULL DEFAULT 'once',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS maintenance_alerts (
+			alert_id TEXT PRIMARY KEY,
+			window_id INTEGER NOT NULL,
+			agent_id TEXT NOT NULL DEFAULT '',
+			alert_type TEXT NOT NULL DEFAULT '',
+			recorded_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS escalation_steps (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			enabled BOOLEAN NOT NULL DEFAULT TRUE,
+			alert_type TEXT NOT NULL DEFAULT '',
+			after_hours INTEGER NOT NULL,
+			priority_name TEXT NOT NULL DEFAULT '',
+			owner_id INTEGER,
+			owner_name TEXT NOT NULL DEFAULT '',
+			note TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS ticket_escalations (
+			ticket_id INTEGER NOT NULL,
+			step_id INTEGER NOT NULL,
+			fired_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (ticket_id, step_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS alert_type_policies (
+			alert_type TEXT PRIMARY KEY,
+			grace_minutes INTEGER NOT NULL DEFAULT 0,
+			flap_threshold INTEGER NOT NULL DEFAULT 0,
+			flap_window_hours INTEGER NOT NULL DEFAULT 24,
+			flap_grace_minutes INTEGER NOT NULL DEFAULT 0,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
 	}
 
 	for _, query := range queries {
@@ -84,28 +211,128 @@ func (db *DB) createTables() error {
 		}
 	}
 
+	// Columns added after the first release; CREATE TABLE IF NOT EXISTS won't add them to existing databases
+	columns := []struct {
+		table, name, definition string
+	}{
+		// Existing auto-assign configs only ever meant "owner", so default that on
+		{"ticketing_config", "assign_owner", "BOOLEAN DEFAULT TRUE"},
+		{"ticketing_config", "assign_resource", "BOOLEAN DEFAULT FALSE"},
+		{"ticketing_config", "schedule_tech", "BOOLEAN DEFAULT FALSE"},
+		{"ticketing_config", "schedule_minutes", "INTEGER DEFAULT 60"},
+		{"alert_ticket_mappings", "agent_id", "TEXT"},
+		{"alert_ticket_mappings", "alert_type", "TEXT"},
+		{"alert_ticket_mappings", "correlation_key", "TEXT"},
+		{"ticketing_config", "correlation_mode", "TEXT DEFAULT 'off'"},
+		{"ticketing_config", "correlation_window_minutes", "INTEGER DEFAULT 60"},
+		{"pending_tickets", "correlation_key", "TEXT NOT NULL DEFAULT ''"},
+		{"ticketing_config", "reopen_mode", "TEXT DEFAULT 'off'"},
+		{"ticketing_config", "reopen_window_days", "INTEGER DEFAULT 7"},
+		{"ticketing_config", "storm_client_limit", "INTEGER DEFAULT 0"},
+		{"ticketing_config", "storm_total_limit", "INTEGER DEFAULT 0"},
+		{"alert_ticket_mappings", "agent_name", "TEXT"},
+		{"alert_ticket_mappings", "resolution", "TEXT"},
+		{"ticketing_config", "storage_resolve_percent", "INTEGER DEFAULT 80"},
+		{"alert_type_policies", "verify_backups", "INTEGER DEFAULT 0"},
+		{"alert_type_policies", "verify_hours", "INTEGER DEFAULT 0"},
+		{"alert_type_policies", "verify_status", "TEXT DEFAULT ''"},
+		{"alert_ticket_mappings", "verifying_since", "DATETIME"},
+		{"alert_ticket_mappings", "client_id", "TEXT"},
+		{"client_mappings", "sync_policy", "TEXT NOT NULL DEFAULT ''"},
+		{"ticketing_config", "sync_policy", "TEXT DEFAULT 'two_way'"},
+		{"alert_ticket_mappings", "alert_created_at", "DATETIME"},
+		{"pending_tickets", "client_id", "TEXT NOT NULL DEFAULT ''"},
+	}
+
+	for _, column := range columns {
+		if err := db.addColumnIfMissing(column.table, column.name, column.definition); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addColumnIfMissing adds a column to an existing table unless it is already there
+func (db *DB) addColumnIfMissing(table, column, definition string) error {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   bool
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return fmt.Errorf("failed to read columns of %s: %w", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+
+	query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)
+	if _, err := db.conn.Exec(query); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+
 	return nil
 }
 
-func (db *DB) SaveClientMapping(mapping *models.ClientMapping) error {
+func (db *DB) SaveClientMapping(ctx context.Context, mapping *models.ClientMapping) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	// Remapping a client keeps its sync policy unless a new one is given
 	query := `INSERT OR REPLACE INTO client_mappings
-		(slide_client_id, slide_client_name, connectwise_id, connectwise_name)
-		VALUES (?, ?, ?, ?)`
+		(slide_client_id, slide_client_name, connectwise_id, connectwise_name, sync_policy)
+		VALUES (?, ?, ?, ?, COALESCE(NULLIF(?, ''),
+			(SELECT sync_policy FROM client_mappings WHERE slide_client_id = ?), ''))`
 
-	_, err := db.conn.Exec(query, mapping.SlideClientID, mapping.SlideClientName,
-		mapping.ConnectWiseID, mapping.ConnectWiseName)
+	_, err := db.conn.ExecContext(ctx, query, mapping.SlideClientID, mapping.SlideClientName,
+		mapping.ConnectWiseID, mapping.ConnectWiseName, mapping.SyncPolicy, mapping.SlideClientID)
 
 	return err
 }
 
-func (db *DB) GetClientMapping(slideClientID string) (*models.ClientMapping, error) {
-	query := `SELECT id, slide_client_id, slide_client_name, connectwise_id, connectwise_name, created_at
+// SetClientSyncPolicy overrides the global sync policy for a mapped client; an empty policy clears the override
+func (db *DB) SetClientSyncPolicy(ctx context.Context, slideClientID string, policy models.SyncPolicy) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := db.conn.ExecContext(ctx, `UPDATE client_mappings SET sync_policy = ? WHERE slide_client_id = ?`,
+		policy, slideClientID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("client %s is not mapped", slideClientID)
+	}
+	return nil
+}
+
+func (db *DB) GetClientMapping(ctx context.Context, slideClientID string) (*models.ClientMapping, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT id, slide_client_id, slide_client_name, connectwise_id, connectwise_name,
+		COALESCE(sync_policy, ''), created_at
 		FROM client_mappings WHERE slide_client_id = ?`
 
 	var mapping models.ClientMapping
-	err := db.conn.QueryRow(query, slideClientID).Scan(
+	err := db.conn.QueryRowContext(ctx, query, slideClientID).Scan(
 		&mapping.ID, &mapping.SlideClientID, &mapping.SlideClientName,
-		&mapping.ConnectWiseID, &mapping.ConnectWiseName, &mapping.CreatedAt,
+		&mapping.ConnectWiseID, &mapping.ConnectWiseName, &mapping.SyncPolicy, &mapping.CreatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -115,22 +342,65 @@ func (db *DB) GetClientMapping(slideClientID string) (*models.ClientMapping, err
 	return &mapping, err
 }
 
-func (db *DB) SaveAlertTicketMapping(mapping *models.AlertTicketMapping) error {
-	query := `INSERT INTO alert_ticket_mappings (alert_id, ticket_id) VALUES (?, ?)`
-	_, err := db.conn.Exec(query, mapping.AlertID, mapping.TicketID)
+// alertTicketMappingColumns is the SELECT list scanAlertTicketMapping expects.
+// Rows from before agent tracking have NULL agent_id/agent_name/client_id/alert_type/correlation_key/resolution.
+const alertTicketMappingColumns = `id, alert_id, ticket_id, COALESCE(agent_id, ''), COALESCE(agent_name, ''), COALESCE(client_id, ''),
+	COALESCE(alert_type, ''), COALESCE(correlation_key, ''), COALESCE(resolution, ''), verifying_since, alert_created_at,
+	created_at, closed_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAlertTicketMapping(row rowScanner) (models.AlertTicketMapping, error) {
+	var mapping models.AlertTicketMapping
+	err := row.Scan(&mapping.ID, &mapping.AlertID, &mapping.TicketID,
+		&mapping.AgentID, &mapping.AgentName, &mapping.ClientID, &mapping.AlertType, &mapping.CorrelationKey, &mapping.Resolution, &mapping.VerifyingSince, &mapping.AlertCreatedAt, &mapping.CreatedAt, &mapping.ClosedAt)
+	return mapping, err
+}
+
+func (db *DB) queryAlertTicketMappings(ctx context.Context, where string, args ...interface{}) ([]models.AlertTicketMapping, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT ` + alertTicketMappingColumns + ` FROM alert_ticket_mappings ` + where
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mappings []models.AlertTicketMapping
+	for rows.Next() {
+		mapping, err := scanAlertTicketMapping(rows)
+		if err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, mapping)
+	}
+
+	return mappings, rows.Err()
+}
+
+func (db *DB) SaveAlertTicketMapping(ctx context.Context, mapping *models.AlertTicketMapping) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO alert_ticket_mappings (alert_id, ticket_id, agent_id, agent_name, client_id, alert_type,
+		correlation_key, alert_created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := db.conn.ExecContext(ctx, query, mapping.AlertID, mapping.TicketID, mapping.AgentID, mapping.AgentName,
+		mapping.ClientID, mapping.AlertType, mapping.CorrelationKey, mapping.AlertCreatedAt)
 	return err
 }
 
-func (db *DB) GetAlertTicketMapping(alertID string) (*models.AlertTicketMapping, error) {
-	query := `SELECT id, alert_id, ticket_id, created_at, closed_at
-		FROM alert_ticket_mappings WHERE alert_id = ?`
+func (db *DB) GetAlertTicketMapping(ctx context.Context, alertID string) (*models.AlertTicketMapping, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
 
-	var mapping models.AlertTicketMapping
-	err := db.conn.QueryRow(query, alertID).Scan(
-		&mapping.ID, &mapping.AlertID, &mapping.TicketID,
-		&mapping.CreatedAt, &mapping.ClosedAt,
-	)
+	query := `SELECT ` + alertTicketMappingColumns + ` FROM alert_ticket_mappings WHERE alert_id = ?`
 
+	mapping, err := scanAlertTicketMapping(db.conn.QueryRowContext(ctx, query, alertID))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -138,46 +408,126 @@ func (db *DB) GetAlertTicketMapping(alertID string) (*models.AlertTicketMapping,
 	return &mapping, err
 }
 
-func (db *DB) CloseAlertTicketMapping(alertID string) error {
+// GetOpenAlertTicketMappings returns every alert-ticket mapping that has not been closed yet, oldest first
+func (db *DB) GetOpenAlertTicketMappings(ctx context.Context) ([]models.AlertTicketMapping, error) {
+	return db.queryAlertTicketMappings(ctx, `WHERE closed_at IS NULL ORDER BY created_at ASC`)
+}
+
+// GetOpenAlertTicketMappingsByAgent returns the open mappings for alerts raised by agentID, oldest first
+func (db *DB) GetOpenAlertTicketMappingsByAgent(ctx context.Context, agentID string) ([]models.AlertTicketMapping, error) {
+	return db.queryAlertTicketMappings(ctx, `WHERE closed_at IS NULL AND agent_id = ? ORDER BY created_at ASC`, agentID)
+}
+
+// GetOpenAlertTicketMappingsByTicket returns the open mappings pointing at a ConnectWise ticket
+func (db *DB) GetOpenAlertTicketMappingsByTicket(ctx context.Context, ticketID int) ([]models.AlertTicketMapping, error) {
+	return db.queryAlertTicketMappings(ctx, `WHERE closed_at IS NULL AND ticket_id = ? ORDER BY created_at ASC`, ticketID)
+}
+
+// GetOpenAlertTicketMappingsByCorrelationKey returns open mappings for correlated alerts, newest first
+func (db *DB) GetOpenAlertTicketMappingsByCorrelationKey(ctx context.Context, key string) ([]models.AlertTicketMapping, error) {
+	return db.queryAlertTicketMappings(ctx, `WHERE closed_at IS NULL AND correlation_key = ? ORDER BY created_at DESC, id DESC`, key)
+}
+
+// GetLastClosedAlertTicketMapping returns the most recently closed mapping for an agent's
+// alerts of alertType, or nil if none has been closed
+func (db *DB) GetLastClosedAlertTicketMapping(ctx context.Context, agentID, alertType string) (*models.AlertTicketMapping, error) {
+	mappings, err := db.queryAlertTicketMappings(ctx,
+		`WHERE closed_at IS NOT NULL AND agent_id = ? AND alert_type = ? ORDER BY closed_at DESC, id DESC LIMIT 1`,
+		agentID, alertType)
+	if err != nil || len(mappings) == 0 {
+		return nil, err
+	}
+	return &mappings[0], nil
+}
+
+func (db *DB) CloseAlertTicketMapping(ctx context.Context, alertID string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
 	query := `UPDATE alert_ticket_mappings SET closed_at = CURRENT_TIMESTAMP WHERE alert_id = ?`
-	_, err := db.conn.Exec(query, alertID)
+	_, err := db.conn.ExecContext(ctx, query, alertID)
+	return err
+}
+
+// SetAlertResolution records the evidence an alert was auto-resolved on against its ticket mapping
+func (db *DB) SetAlertResolution(ctx context.Context, alertID, resolution string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	_, err := db.conn.ExecContext(ctx, `UPDATE alert_ticket_mappings SET resolution = ? WHERE alert_id = ?`, resolution, alertID)
+	return err
+}
+
+// SetAlertVerifying marks an alert as pending backup verification since the given time,
+// or clears the mark when since is nil
+func (db *DB) SetAlertVerifying(ctx context.Context, alertID string, since *time.Time) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	_, err := db.conn.ExecContext(ctx, `UPDATE alert_ticket_mappings SET verifying_since = ? WHERE alert_id = ?`, since, alertID)
 	return err
 }
 
 // Ticketing methods
-func (db *DB) SaveTicketingConfig(config *models.TicketingConfig) error {
+func (db *DB) SaveTicketingConfig(ctx context.Context, config *models.TicketingConfig) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
 	query := `INSERT OR REPLACE INTO ticketing_config
 		(board_id, board_name, status_id, status_name, priority_id, priority_name,
 		 type_id, type_name, ticket_summary, ticket_template, auto_assign_tech,
-		 technician_id, technician_name, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
+		 technician_id, technician_name, assign_owner, assign_resource,
+		 schedule_tech, schedule_minutes, correlation_mode, correlation_window_minutes,
+		 reopen_mode, reopen_window_days, storm_client_limit, storm_total_limit,
+		 storage_resolve_percent, sync_policy, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
 
-	_, err := db.conn.Exec(query,
+	_, err := db.conn.ExecContext(ctx, query,
 		config.BoardID, config.BoardName,
 		config.StatusID, config.StatusName,
 		config.PriorityID, config.PriorityName,
 		config.TypeID, config.TypeName,
 		config.TicketSummary, config.TicketTemplate,
 		config.AutoAssignTech, config.TechnicianID, config.TechnicianName,
+		config.AssignOwner, config.AssignResource,
+		config.ScheduleTech, config.ScheduleMinutes,
+		config.CorrelationMode, config.CorrelationWindowMinutes,
+		config.ReopenMode, config.ReopenWindowDays,
+		config.StormClientLimit, config.StormTotalLimit,
+		config.StorageResolvePercent, config.SyncPolicy,
 	)
 
 	return err
 }
 
-func (db *DB) GetTicketingConfig() (*models.TicketingConfig, error) {
+func (db *DB) GetTicketingConfig(ctx context.Context) (*models.TicketingConfig, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
 	query := `SELECT id, board_id, board_name, status_id, status_name, priority_id, priority_name,
 		type_id, type_name, ticket_summary, ticket_template, auto_assign_tech,
-		technician_id, technician_name, created_at, updated_at
+		technician_id, technician_name, assign_owner, assign_resource,
+		schedule_tech, schedule_minutes, COALESCE(correlation_mode, 'off'),
+		COALESCE(correlation_window_minutes, 60), COALESCE(reopen_mode, 'off'),
+		COALESCE(reopen_window_days, 7), COALESCE(storm_client_limit, 0),
+		COALESCE(storm_total_limit, 0), COALESCE(storage_resolve_percent, 80),
+		COALESCE(sync_policy, 'two_way'), created_at, updated_at
 		FROM ticketing_config ORDER BY updated_at DESC LIMIT 1`
 
 	var config models.TicketingConfig
-	err := db.conn.QueryRow(query).Scan(
+	err := db.conn.QueryRowContext(ctx, query).Scan(
 		&config.ID, &config.BoardID, &config.BoardName,
 		&config.StatusID, &config.StatusName,
 		&config.PriorityID, &config.PriorityName,
 		&config.TypeID, &config.TypeName,
 		&config.TicketSummary, &config.TicketTemplate,
 		&config.AutoAssignTech, &config.TechnicianID, &config.TechnicianName,
+		&config.AssignOwner, &config.AssignResource,
+		&config.ScheduleTech, &config.ScheduleMinutes,
+		&config.CorrelationMode, &config.CorrelationWindowMinutes,
+		&config.ReopenMode, &config.ReopenWindowDays,
+		&config.StormClientLimit, &config.StormTotalLimit,
+		&config.StorageResolvePercent, &config.SyncPolicy,
 		&config.CreatedAt, &config.UpdatedAt,
 	)
 
@@ -188,8 +538,785 @@ func (db *DB) GetTicketingConfig() (*models.TicketingConfig, error) {
 	return &config, err
 }
 
-func (db *DB) DeleteTicketingConfig() error {
+func (db *DB) DeleteTicketingConfig(ctx context.Context) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
 	query := `DELETE FROM ticketing_config`
-	_, err := db.conn.Exec(query)
+	_, err := db.conn.ExecContext(ctx, query)
+	return err
+}
+
+// SaveBoardResolvedStatus sets the status auto-resolved tickets on a board are moved to
+func (db *DB) SaveBoardResolvedStatus(ctx context.Context, status *models.BoardResolvedStatus) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT OR REPLACE INTO board_resolved_statuses
+		(board_id, board_name, status_id, status_name, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)`
+
+	_, err := db.conn.ExecContext(ctx, query, status.BoardID, status.BoardName,
+		status.StatusID, status.StatusName)
+	return err
+}
+
+// GetBoardResolvedStatuses returns every per-board resolved status override
+func (db *DB) GetBoardResolvedStatuses(ctx context.Context) ([]models.BoardResolvedStatus, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT board_id, board_name, status_id, status_name, updated_at
+		FROM board_resolved_statuses ORDER BY board_name`
+
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var statuses []models.BoardResolvedStatus
+	for rows.Next() {
+		var status models.BoardResolvedStatus
+		if err := rows.Scan(&status.BoardID, &status.BoardName, &status.StatusID,
+			&status.StatusName, &status.UpdatedAt); err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, rows.Err()
+}
+
+// DeleteBoardResolvedStatus reverts a board to using its own closed status
+func (db *DB) DeleteBoardResolvedStatus(ctx context.Context, boardID int) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM board_resolved_statuses WHERE board_id = ?`
+	_, err := db.conn.ExecContext(ctx, query, boardID)
+	return err
+}
+
+// SaveAlertTypePolicy sets the grace period, flap suppression and backup verification for an alert type
+func (db *DB) SaveAlertTypePolicy(ctx context.Context, policy *models.AlertTypePolicy) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT OR REPLACE INTO alert_type_policies
+		(alert_type, grace_minutes, flap_threshold, flap_window_hours, flap_grace_minutes,
+		 verify_backups, verify_hours, verify_status, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
+
+	_, err := db.conn.ExecContext(ctx, query, policy.AlertType, policy.GraceMinutes,
+		policy.FlapThreshold, policy.FlapWindowHours, policy.FlapGraceMinutes,
+		policy.VerifyBackups, policy.VerifyHours, policy.VerifyStatus)
+	return err
+}
+
+func (db *DB) queryAlertTypePolicies(ctx context.Context, where string, args ...interface{}) ([]models.AlertTypePolicy, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT alert_type, grace_minutes, flap_threshold, flap_window_hours, flap_grace_minutes,
+		COALESCE(verify_backups, 0), COALESCE(verify_hours, 0), COALESCE(verify_status, ''), updated_at
+		FROM alert_type_policies ` + where
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []models.AlertTypePolicy
+	for rows.Next() {
+		var policy models.AlertTypePolicy
+		if err := rows.Scan(&policy.AlertType, &policy.GraceMinutes, &policy.FlapThreshold,
+			&policy.FlapWindowHours, &policy.FlapGraceMinutes,
+			&policy.VerifyBackups, &policy.VerifyHours, &policy.VerifyStatus, &policy.UpdatedAt); err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+
+	return policies, rows.Err()
+}
+
+// GetAlertTypePolicy returns the policy for an alert type, or nil if it has none
+func (db *DB) GetAlertTypePolicy(ctx context.Context, alertType string) (*models.AlertTypePolicy, error) {
+	policies, err := db.queryAlertTypePolicies(ctx, `WHERE alert_type = ?`, alertType)
+	if err != nil || len(policies) == 0 {
+		return nil, err
+	}
+	return &policies[0], nil
+}
+
+// GetAlertTypePolicies returns every alert type policy
+func (db *DB) GetAlertTypePolicies(ctx context.Context) ([]models.AlertTypePolicy, error) {
+	return db.queryAlertTypePolicies(ctx, `ORDER BY alert_type`)
+}
+
+// DeleteAlertTypePolicy makes an alert type ticket immediately again
+func (db *DB) DeleteAlertTypePolicy(ctx context.Context, alertType string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	_, err := db.conn.ExecContext(ctx, `DELETE FROM alert_type_policies WHERE alert_type = ?`, alertType)
+	return err
+}
+
+// SaveRoutingRule creates a rule at the end of the list, or updates it if it has an ID
+func (db *DB) SaveRoutingRule(ctx context.Context, rule *models.RoutingRule) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	args := []interface{}{rule.Name, rule.Enabled, rule.Action,
+		rule.MatchAlertType, rule.MatchClientID, rule.MatchCompanyID, rule.MatchDevice,
+		rule.MatchAgentName, rule.MatchMessage,
+		rule.BoardID, rule.BoardName, rule.StatusName, rule.PriorityName, rule.TypeName,
+		rule.SubTypeName, rule.ItemName, rule.OwnerID, rule.OwnerName,
+		rule.SummaryTemplate, rule.DescriptionTemplate}
+
+	if rule.ID != 0 {
+		query := `UPDATE routing_rules SET name = ?, enabled = ?, action = ?,
+			match_alert_type = ?, match_client_id = ?, match_company_id = ?, match_device = ?,
+			match_agent_name = ?, match_message = ?,
+			board_id = ?, board_name = ?, status_name = ?, priority_name = ?, type_name = ?,
+			subtype_name = ?, item_name = ?, owner_id = ?, owner_name = ?,
+			summary_template = ?, description_template = ?, updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?`
+		result, err := db.conn.ExecContext(ctx, query, append(args, rule.ID)...)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("routing rule %d not found", rule.ID)
+		}
+		return nil
+	}
+
+	query := `INSERT INTO routing_rules (name, enabled, action,
+		match_alert_type, match_client_id, match_company_id, match_device, match_agent_name, match_message,
+		board_id, board_name, status_name, priority_name, type_name, subtype_name, item_name,
+		owner_id, owner_name, summary_template, description_template, position)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+			(SELECT COALESCE(MAX(position), 0) + 1 FROM routing_rules))`
+	result, err := db.conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	rule.ID = int(id)
+	return nil
+}
+
+// GetRoutingRules returns every routing rule in the order they are tried
+func (db *DB) GetRoutingRules(ctx context.Context) ([]models.RoutingRule, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT id, position, name, enabled, action,
+		match_alert_type, match_client_id, match_company_id, match_device, match_agent_name, match_message,
+		board_id, board_name, status_name, priority_name, type_name, subtype_name, item_name,
+		owner_id, owner_name, summary_template, description_template, created_at, updated_at
+		FROM routing_rules ORDER BY position, id`
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.RoutingRule
+	for rows.Next() {
+		var r models.RoutingRule
+		if err := rows.Scan(&r.ID, &r.Position, &r.Name, &r.Enabled, &r.Action,
+			&r.MatchAlertType, &r.MatchClientID, &r.MatchCompanyID, &r.MatchDevice,
+			&r.MatchAgentName, &r.MatchMessage,
+			&r.BoardID, &r.BoardName, &r.StatusName, &r.PriorityName, &r.TypeName,
+			&r.SubTypeName, &r.ItemName, &r.OwnerID, &r.OwnerName,
+			&r.SummaryTemplate, &r.DescriptionTemplate, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+
+	return rules, rows.Err()
+}
+
+// ReorderRoutingRules sets the order rules are tried in to that of ids
+func (db *DB) ReorderRoutingRules(ctx context.Context, ids []int) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for i, id := range ids {
+		if _, err := tx.ExecContext(ctx, `UPDATE routing_rules SET position = ? WHERE id = ?`, i+1, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteRoutingRule removes a routing rule
+func (db *DB) DeleteRoutingRule(ctx context.Context, id int) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	_, err := db.conn.ExecContext(ctx, `DELETE FROM routing_rules WHERE id = ?`, id)
+	return err
+}
+
+// SaveMaintenanceWindow creates a window, or updates it if it has an ID
+func (db *DB) SaveMaintenanceWindow(ctx context.Context, window *models.MaintenanceWindow) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	if window.ID != 0 {
+		query := `UPDATE maintenance_windows SET name = ?, scope = ?, scope_id = ?, timezone = ?,
+			starts_at = ?, duration_minutes = ?, recurrence = ? WHERE id = ?`
+		result, err := db.conn.ExecContext(ctx, query, window.Name, window.Scope, window.ScopeID,
+			window.Timezone, window.StartsAt, window.DurationMinutes, window.Recurrence, window.ID)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("maintenance window %d not found", window.ID)
+		}
+		return nil
+	}
+
+	query := `INSERT INTO maintenance_windows
+		(name, scope, scope_id, timezone, starts_at, duration_minutes, recurrence)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	result, err := db.conn.ExecContext(ctx, query, window.Name, window.Scope, window.ScopeID,
+		window.Timezone, window.StartsAt, window.DurationMinutes, window.Recurrence)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	window.ID = int(id)
+	return nil
+}
+
+// GetMaintenanceWindows returns every maintenance window
+func (db *DB) GetMaintenanceWindows(ctx context.Context) ([]models.MaintenanceWindow, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT id, name, scope, scope_id, timezone, starts_at, duration_minutes, recurrence, created_at
+		FROM maintenance_windows ORDER BY starts_at`
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var windows []models.MaintenanceWindow
+	for rows.Next() {
+		var w models.MaintenanceWindow
+		if err := rows.Scan(&w.ID, &w.Name, &w.Scope, &w.ScopeID, &w.Timezone, &w.StartsAt,
+			&w.DurationMinutes, &w.Recurrence, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+
+	return windows, rows.Err()
+}
+
+// DeleteMaintenanceWindow removes a window along with the alerts it recorded
+func (db *DB) DeleteMaintenanceWindow(ctx context.Context, id int) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM maintenance_alerts WHERE window_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM maintenance_windows WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RecordMaintenanceAlert notes an alert held back by a window. An alert is recorded
+// against the first window that held it.
+func (db *DB) RecordMaintenanceAlert(ctx context.Context, alert *models.MaintenanceAlert) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT OR IGNORE INTO maintenance_alerts (alert_id, window_id, agent_id, alert_type)
+		VALUES (?, ?, ?, ?)`
+	_, err := db.conn.ExecContext(ctx, query, alert.AlertID, alert.WindowID, alert.AgentID, alert.AlertType)
+	return err
+}
+
+// GetMaintenanceAlerts returns the alerts a window has held back, newest first
+func (db *DB) GetMaintenanceAlerts(ctx context.Context, windowID int) ([]models.MaintenanceAlert, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT alert_id, window_id, agent_id, alert_type, recorded_at
+		FROM maintenance_alerts WHERE window_id = ? ORDER BY recorded_at DESC`
+	rows, err := db.conn.QueryContext(ctx, query, windowID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alerts []models.MaintenanceAlert
+	for rows.Next() {
+		var a models.MaintenanceAlert
+		if err := rows.Scan(&a.AlertID, &a.WindowID, &a.AgentID, &a.AlertType, &a.RecordedAt); err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+
+	return alerts, rows.Err()
+}
+
+// SaveEscalationStep creates an escalation step, or updates it if it has an ID
+func (db *DB) SaveEscalationStep(ctx context.Context, step *models.EscalationStep) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	args := []interface{}{step.Name, step.Enabled, step.AlertType, step.AfterHours,
+		step.PriorityName, step.OwnerID, step.OwnerName, step.Note}
+
+	if step.ID != 0 {
+		query := `UPDATE escalation_steps SET name = ?, enabled = ?, alert_type = ?, after_hours = ?,
+			priority_name = ?, owner_id = ?, owner_name = ?, note = ?, updated_at = CURRENT_TIMESTAMP
+			WHERE id = ?`
+		result, err := db.conn.ExecContext(ctx, query, append(args, step.ID)...)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			return fmt.Errorf("escalation step %d not found", step.ID)
+		}
+		return nil
+	}
+
+	query := `INSERT INTO escalation_steps
+		(name, enabled, alert_type, after_hours, priority_name, owner_id, owner_name, note)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := db.conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	step.ID = int(id)
+	return nil
+}
+
+// GetEscalationSteps returns every escalation step, earliest first
+func (db *DB) GetEscalationSteps(ctx context.Context) ([]models.EscalationStep, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT id, name, enabled, alert_type, after_hours, priority_name, owner_id, owner_name,
+		note, created_at, updated_at
+		FROM escalation_steps ORDER BY after_hours, id`
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var steps []models.EscalationStep
+	for rows.Next() {
+		var s models.EscalationStep
+		if err := rows.Scan(&s.ID, &s.Name, &s.Enabled, &s.AlertType, &s.AfterHours, &s.PriorityName,
+			&s.OwnerID, &s.OwnerName, &s.Note, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		steps = append(steps, s)
+	}
+
+	return steps, rows.Err()
+}
+
+// DeleteEscalationStep removes an escalation step along with its record of the tickets it fired on
+func (db *DB) DeleteEscalationStep(ctx context.Context, id int) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM ticket_escalations WHERE step_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM escalation_steps WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RecordTicketEscalation notes that a step has fired on a ticket so it never fires there again
+func (db *DB) RecordTicketEscalation(ctx context.Context, ticketID, stepID int) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	_, err := db.conn.ExecContext(ctx,
+		`INSERT OR IGNORE INTO ticket_escalations (ticket_id, step_id) VALUES (?, ?)`, ticketID, stepID)
 	return err
-}
\ No newline at end of file
+}
+
+// GetTicketEscalations returns every step fired on any ticket, oldest first
+func (db *DB) GetTicketEscalations(ctx context.Context) ([]models.TicketEscalation, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT ticket_id, step_id, fired_at FROM ticket_escalations ORDER BY fired_at, ticket_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var escalations []models.TicketEscalation
+	for rows.Next() {
+		var e models.TicketEscalation
+		if err := rows.Scan(&e.TicketID, &e.StepID, &e.FiredAt); err != nil {
+			return nil, err
+		}
+		escalations = append(escalations, e)
+	}
+
+	return escalations, rows.Err()
+}
+
+// circuitBreakerSetting holds when the alert storm circuit breaker tripped; empty while it is closed
+const circuitBreakerSetting = "storm_circuit_breaker_tripped_at"
+
+// GetCircuitBreakerTrippedAt returns when the alert storm circuit breaker paused ticket
+// creation, or nil if it is not tripped
+func (db *DB) GetCircuitBreakerTrippedAt(ctx context.Context) (*time.Time, error) {
+	value, err := db.GetSetting(ctx, circuitBreakerSetting)
+	if err != nil || value == "" {
+		return nil, err
+	}
+
+	trippedAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse circuit breaker setting %q: %w", value, err)
+	}
+	return &trippedAt, nil
+}
+
+// TripCircuitBreaker pauses ticket creation until ResetCircuitBreaker is called
+func (db *DB) TripCircuitBreaker(ctx context.Context, at time.Time) error {
+	return db.SetSetting(ctx, circuitBreakerSetting, at.UTC().Format(time.RFC3339))
+}
+
+// ResetCircuitBreaker lets ticket creation resume
+func (db *DB) ResetCircuitBreaker(ctx context.Context) error {
+	return db.SetSetting(ctx, circuitBreakerSetting, "")
+}
+
+// GetSetting returns a stored setting, or "" if it has never been set
+func (db *DB) GetSetting(ctx context.Context, key string) (string, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var value string
+	err := db.conn.QueryRowContext(ctx, `SELECT value FROM app_settings WHERE key = ?`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value, err
+}
+
+// SetSetting stores a setting, replacing any previous value
+func (db *DB) SetSetting(ctx context.Context, key, value string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT OR REPLACE INTO app_settings (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)`
+	_, err := db.conn.ExecContext(ctx, query, key, value)
+	return err
+}
+
+// workItemColumns is the SELECT list scanWorkItem expects
+const workItemColumns = `id, action, alert_id, ticket_id, payload, status, attempts,
+	next_attempt_at, last_error, created_at, updated_at`
+
+func scanWorkItem(row rowScanner) (models.WorkItem, error) {
+	var item models.WorkItem
+	err := row.Scan(&item.ID, &item.Action, &item.AlertID, &item.TicketID, &item.Payload,
+		&item.Status, &item.Attempts, &item.NextAttemptAt, &item.LastError,
+		&item.CreatedAt, &item.UpdatedAt)
+	return item, err
+}
+
+func (db *DB) queryWorkItems(ctx context.Context, where string, args ...interface{}) ([]models.WorkItem, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	rows, err := db.conn.QueryContext(ctx, `SELECT `+workItemColumns+` FROM work_queue `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.WorkItem
+	for rows.Next() {
+		item, err := scanWorkItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// storedTime normalises a timestamp we compare in SQL so stored values order correctly as text
+func storedTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Second)
+}
+
+// EnqueueWorkItem queues a failed operation. If the same action is already queued for
+// the alert, its details and last error are refreshed but its attempt count is kept.
+func (db *DB) EnqueueWorkItem(ctx context.Context, item *models.WorkItem) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT INTO work_queue
+		(action, alert_id, ticket_id, payload, status, attempts, next_attempt_at, last_error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (action, alert_id) DO UPDATE SET
+			ticket_id = excluded.ticket_id,
+			payload = excluded.payload,
+			last_error = excluded.last_error,
+			updated_at = CURRENT_TIMESTAMP`
+
+	_, err := db.conn.ExecContext(ctx, query, item.Action, item.AlertID, item.TicketID, item.Payload,
+		item.Status, item.Attempts, storedTime(item.NextAttemptAt), item.LastError)
+	return err
+}
+
+// GetWorkItem returns the queued item for action on alertID, or nil if there is none
+func (db *DB) GetWorkItem(ctx context.Context, action models.WorkAction, alertID string) (*models.WorkItem, error) {
+	items, err := db.queryWorkItems(ctx, `WHERE action = ? AND alert_id = ?`, action, alertID)
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// GetDueWorkItems returns up to limit pending items whose next attempt is due, oldest first
+func (db *DB) GetDueWorkItems(ctx context.Context, now time.Time, limit int) ([]models.WorkItem, error) {
+	return db.queryWorkItems(ctx, `WHERE status = ? AND next_attempt_at <= ? ORDER BY next_attempt_at ASC LIMIT ?`,
+		models.WorkPending, storedTime(now), limit)
+}
+
+// GetWorkItems returns every queued item, failed ones first
+func (db *DB) GetWorkItems(ctx context.Context) ([]models.WorkItem, error) {
+	return db.queryWorkItems(ctx, `ORDER BY status = ? DESC, next_attempt_at ASC`, models.WorkFailed)
+}
+
+// GetWorkItemsByStatus returns the queued items with status, oldest first
+func (db *DB) GetWorkItemsByStatus(ctx context.Context, status string) ([]models.WorkItem, error) {
+	return db.queryWorkItems(ctx, `WHERE status = ? ORDER BY created_at ASC`, status)
+}
+
+// UpdateWorkItem records the outcome of a failed attempt
+func (db *DB) UpdateWorkItem(ctx context.Context, item *models.WorkItem) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE work_queue SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?,
+		updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	_, err := db.conn.ExecContext(ctx, query, item.Status, item.Attempts,
+		storedTime(item.NextAttemptAt), item.LastError, item.ID)
+	return err
+}
+
+// RetryWorkItem puts an item back in the queue with a fresh set of attempts, due now
+func (db *DB) RetryWorkItem(ctx context.Context, id int) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE work_queue SET status = ?, attempts = 0, next_attempt_at = ?,
+		updated_at = CURRENT_TIMESTAMP WHERE id = ?`
+	result, err := db.conn.ExecContext(ctx, query, models.WorkPending, storedTime(time.Now()), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("work item %d not found", id)
+	}
+	return nil
+}
+
+// DiscardWorkItem drops an item an admin doesn't want retried. A ticket creation is marked
+// skipped instead of removed, as without it the alert would be ticketed again next poll.
+func (db *DB) DiscardWorkItem(ctx context.Context, id int) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE work_queue SET status = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND action = ?`
+	result, err := db.conn.ExecContext(ctx, query, models.WorkSkipped, id, models.WorkCreateTicket)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		return nil
+	}
+
+	_, err = db.conn.ExecContext(ctx, `DELETE FROM work_queue WHERE id = ?`, id)
+	return err
+}
+
+// DeleteWorkItem removes an item from the queue, once done or no longer wanted
+func (db *DB) DeleteWorkItem(ctx context.Context, id int) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	_, err := db.conn.ExecContext(ctx, `DELETE FROM work_queue WHERE id = ?`, id)
+	return err
+}
+
+// SavePendingTicket records that a ticket is about to be created for an alert
+func (db *DB) SavePendingTicket(ctx context.Context, pending *models.PendingTicket) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `INSERT OR REPLACE INTO pending_tickets (alert_id, company_id, client_id, agent_id, alert_type, correlation_key, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
+	_, err := db.conn.ExecContext(ctx, query, pending.AlertID, pending.CompanyID, pending.ClientID, pending.AgentID,
+		pending.AlertType, pending.CorrelationKey)
+	return err
+}
+
+func (db *DB) queryPendingTickets(ctx context.Context, where string, args ...interface{}) ([]models.PendingTicket, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT alert_id, company_id, client_id, agent_id, alert_type, correlation_key, created_at FROM pending_tickets ` + where
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pending []models.PendingTicket
+	for rows.Next() {
+		var p models.PendingTicket
+		if err := rows.Scan(&p.AlertID, &p.CompanyID, &p.ClientID, &p.AgentID, &p.AlertType, &p.CorrelationKey, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		pending = append(pending, p)
+	}
+
+	return pending, rows.Err()
+}
+
+// GetPendingTicket returns the pending marker for an alert, or nil if there is none
+func (db *DB) GetPendingTicket(ctx context.Context, alertID string) (*models.PendingTicket, error) {
+	pending, err := db.queryPendingTickets(ctx, `WHERE alert_id = ?`, alertID)
+	if err != nil || len(pending) == 0 {
+		return nil, err
+	}
+	return &pending[0], nil
+}
+
+// GetPendingTickets returns every pending marker, oldest first
+func (db *DB) GetPendingTickets(ctx context.Context) ([]models.PendingTicket, error) {
+	return db.queryPendingTickets(ctx, `ORDER BY created_at ASC`)
+}
+
+// DeletePendingTicket clears an alert's pending marker
+func (db *DB) DeletePendingTicket(ctx context.Context, alertID string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	_, err := db.conn.ExecContext(ctx, `DELETE FROM pending_tickets WHERE alert_id = ?`, alertID)
+	return err
+}
+
+// AcquireLease takes or renews the named lease for holder until now+ttl. It succeeds
+// if holder already has the lease or the current holder's lease has expired.
+func (db *DB) AcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	now := storedTime(time.Now())
+	query := `INSERT INTO leases (name, holder, acquired_at, renewed_at, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET
+			holder = excluded.holder,
+			acquired_at = CASE WHEN leases.holder = excluded.holder THEN leases.acquired_at ELSE excluded.acquired_at END,
+			renewed_at = excluded.renewed_at,
+			expires_at = excluded.expires_at
+		WHERE leases.holder = excluded.holder OR leases.expires_at <= excluded.renewed_at`
+
+	result, err := db.conn.ExecContext(ctx, query, name, holder, now, now, storedTime(now.Add(ttl)))
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// ReleaseLease gives up the named lease if holder has it, so another instance can take over at once
+func (db *DB) ReleaseLease(ctx context.Context, name, holder string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	_, err := db.conn.ExecContext(ctx, `DELETE FROM leases WHERE name = ? AND holder = ?`, name, holder)
+	return err
+}
+
+// GetLeases returns every lease with its current holder
+func (db *DB) GetLeases(ctx context.Context) ([]models.Lease, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	query := `SELECT name, holder, acquired_at, renewed_at, expires_at FROM leases ORDER BY name`
+	rows, err := db.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var leases []models.Lease
+	for rows.Next() {
+		var lease models.Lease
+		if err := rows.Scan(&lease.Name, &lease.Holder, &lease.AcquiredAt,
+			&lease.RenewedAt, &lease.ExpiresAt); err != nil {
+			return nil, err
+		}
+		leases = append(leases, lease)
+	}
+
+	return leases, rows.Err()
+}
@@ -0,0 +1,116 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBody caps how much of a failed response body is kept on an APIError
+const maxErrorBody = 2048
+
+// FieldError is a single validation failure reported by the ConnectWise API
+type FieldError struct {
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Resource string `json:"resource"`
+	Field    string `json:"field"`
+}
+
+// APIError is returned by the API clients when a request gets a non-2xx response.
+// Use errors.As to inspect it and branch on the status code.
+type APIError struct {
+	Method     string
+	Endpoint   string
+	StatusCode int
+
+	// Code and Message are parsed from the error body when the API provides them
+	// (ConnectWise returns e.g. {"code":"InvalidObject","message":"...","errors":[...]})
+	Code    string
+	Message string
+	Errors  []FieldError
+
+	// Body is the raw response body, truncated to a couple of KB
+	Body string
+}
+
+// NewAPIError builds an APIError from a failed response, consuming its body
+func NewAPIError(method, endpoint string, resp *http.Response) *APIError {
+	apiErr := &APIError{
+		Method:     method,
+		Endpoint:   endpoint,
+		StatusCode: resp.StatusCode,
+	}
+
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody+1))
+	if len(raw) > maxErrorBody {
+		apiErr.Body = string(raw[:maxErrorBody]) + "...(truncated)"
+	} else {
+		apiErr.Body = string(raw)
+	}
+
+	var parsed struct {
+		Code    string       `json:"code"`
+		Message string       `json:"message"`
+		Error   string       `json:"error"`
+		Errors  []FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(raw, &parsed); err == nil {
+		apiErr.Code = parsed.Code
+		apiErr.Message = parsed.Message
+		if apiErr.Message == "" {
+			apiErr.Message = parsed.Error
+		}
+		apiErr.Errors = parsed.Errors
+	}
+
+	return apiErr
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s: API request failed with status %d", e.Method, e.Endpoint, e.StatusCode)
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+
+	var details []string
+	for _, fieldErr := range e.Errors {
+		if fieldErr.Field != "" {
+			details = append(details, fieldErr.Field+": "+fieldErr.Message)
+		} else if fieldErr.Message != "" {
+			details = append(details, fieldErr.Message)
+		}
+	}
+	if len(details) > 0 {
+		msg += " [" + strings.Join(details, "; ") + "]"
+	}
+
+	if e.Code == "" && e.Message == "" && e.Body != "" {
+		msg += ": " + e.Body
+	}
+
+	return msg
+}
+
+// IsNotFound reports whether the requested object does not exist
+func (e *APIError) IsNotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsUnauthorized reports whether the credentials were rejected
+func (e *APIError) IsUnauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
+
+// IsValidation reports whether the API rejected the payload itself,
+// e.g. an unknown status name for the ticket's board
+func (e *APIError) IsValidation() bool {
+	return e.StatusCode == http.StatusBadRequest ||
+		e.StatusCode == http.StatusUnprocessableEntity ||
+		e.Code == "InvalidObject"
+}
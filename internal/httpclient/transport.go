@@ -0,0 +1,251 @@
+// Package httpclient provides the HTTP plumbing shared by the Slide and
+// ConnectWise API clients: per-API throttling and safe retries with backoff.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Config controls how a Transport throttles and retries requests for one API
+type Config struct {
+	// Name is used to prefix log lines, e.g. "Slide" or "ConnectWise"
+	Name string
+
+	// MaxRetries is the number of additional attempts after the first one
+	MaxRetries int
+	// BaseDelay is the backoff before the first retry; it doubles each attempt
+	BaseDelay time.Duration
+	// MaxDelay caps both the exponential backoff and any Retry-After we honour
+	MaxDelay time.Duration
+
+	// RequestsPerSecond and Burst size the token bucket shared by every request
+	// made through the transport. A zero rate disables throttling.
+	RequestsPerSecond float64
+	Burst             int
+
+	// AttemptTimeout bounds how long a single attempt waits for response headers
+	AttemptTimeout time.Duration
+}
+
+// DefaultConfig returns sensible defaults for a REST API named name
+func DefaultConfig(name string) Config {
+	return Config{
+		Name:              name,
+		MaxRetries:        4,
+		BaseDelay:         500 * time.Millisecond,
+		MaxDelay:          30 * time.Second,
+		RequestsPerSecond: 5,
+		Burst:             10,
+		AttemptTimeout:    30 * time.Second,
+	}
+}
+
+// New builds an *http.Client whose transport throttles and retries according to config.
+// timeout is the overall budget for a call, including every retry and backoff.
+func New(config Config, timeout time.Duration) *http.Client {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.ResponseHeaderTimeout = config.AttemptTimeout
+
+	return &http.Client{
+		Transport: NewTransport(base, config),
+		Timeout:   timeout,
+	}
+}
+
+// Transport is an http.RoundTripper that rate limits outgoing requests and
+// retries transient failures with exponential backoff and jitter.
+//
+// Only idempotent requests are retried after a network error or a 502/503/504,
+// since the server may already have acted on them. A 429 is always retried
+// because the API rejected the request before processing it.
+type Transport struct {
+	base    http.RoundTripper
+	config  Config
+	limiter *RateLimiter
+}
+
+// NewTransport wraps base with throttling and retries
+func NewTransport(base http.RoundTripper, config Config) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	var limiter *RateLimiter
+	if config.RequestsPerSecond > 0 {
+		limiter = NewRateLimiter(config.RequestsPerSecond, config.Burst)
+	}
+
+	return &Transport{
+		base:    base,
+		config:  config,
+		limiter: limiter,
+	}
+}
+
+type idempotentKey struct{}
+
+// WithIdempotent marks requests made with the returned context as safe to retry
+// even though their method (e.g. PATCH) is not idempotent by definition
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// IsIdempotent reports whether req may be replayed without side effects
+func IsIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	marked, _ := req.Context().Value(idempotentKey{}).(bool)
+	return marked
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	idempotent := IsIdempotent(req)
+
+	for attempt := 0; ; attempt++ {
+		if t.limiter != nil {
+			if err := t.limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		attemptReq := req
+		if attempt > 0 {
+			var err error
+			if attemptReq, err = rewind(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+
+		retry, delay := t.shouldRetry(resp, err, idempotent, attempt)
+		if !retry {
+			return resp, err
+		}
+
+		if err != nil {
+			log.Printf("%s API %s %s failed (attempt %d/%d): %v - retrying in %s",
+				t.config.Name, req.Method, req.URL.Path, attempt+1, t.config.MaxRetries+1, err, delay)
+		} else {
+			log.Printf("%s API %s %s returned %d (attempt %d/%d) - retrying in %s",
+				t.config.Name, req.Method, req.URL.Path, resp.StatusCode, attempt+1, t.config.MaxRetries+1, delay)
+			// Drain so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// shouldRetry decides whether another attempt is allowed and how long to wait before it
+func (t *Transport) shouldRetry(resp *http.Response, err error, idempotent bool, attempt int) (bool, time.Duration) {
+	if attempt >= t.config.MaxRetries {
+		return false, 0
+	}
+
+	if err != nil {
+		// Never retry if the caller gave up
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false, 0
+		}
+		return idempotent, t.backoff(attempt)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		if delay, ok := t.retryAfter(resp); ok {
+			return true, delay
+		}
+		return true, t.backoff(attempt)
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if !idempotent {
+			return false, 0
+		}
+		if delay, ok := t.retryAfter(resp); ok {
+			return true, delay
+		}
+		return true, t.backoff(attempt)
+	}
+
+	return false, 0
+}
+
+// backoff returns an exponentially growing delay with equal jitter
+func (t *Transport) backoff(attempt int) time.Duration {
+	delay := t.config.BaseDelay << attempt
+	if delay <= 0 || delay > t.config.MaxDelay {
+		delay = t.config.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	// Equal jitter: anywhere between half and the full delay, so retries never bunch up near zero
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// retryAfter parses a Retry-After header given either in seconds or as an HTTP date
+func (t *Transport) retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if when, err := http.ParseTime(value); err == nil {
+		delay = time.Until(when)
+	} else {
+		return 0, false
+	}
+
+	if delay < 0 {
+		delay = 0
+	}
+	if t.config.MaxDelay > 0 && delay > t.config.MaxDelay {
+		delay = t.config.MaxDelay
+	}
+
+	return delay, true
+}
+
+// rewind returns a copy of req with a fresh body so it can be sent again
+func rewind(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return clone, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, errors.New("request body cannot be replayed for retry")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone.Body = body
+
+	return clone, nil
+}
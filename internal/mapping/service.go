@@ -1,6 +1,7 @@
 package mapping
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -17,10 +18,10 @@ func NewService(db *database.DB) *Service {
 	return &Service{db: db}
 }
 
-func (s *Service) MapClients(slideClients []models.SlideClient, cwClients []models.ConnectWiseClient) error {
+func (s *Service) MapClients(ctx context.Context, slideClients []models.SlideClient, cwClients []models.ConnectWiseClient) error {
 	for _, slideClient := range slideClients {
 		// Check if mapping already exists
-		existing, err := s.db.GetClientMapping(slideClient.ID)
+		existing, err := s.db.GetClientMapping(ctx, slideClient.ID)
 		if err != nil {
 			return fmt.Errorf("failed to check existing mapping for %s: %w", slideClient.ID, err)
 		}
@@ -46,7 +47,7 @@ func (s *Service) MapClients(slideClients []models.SlideClient, cwClients []mode
 			ConnectWiseName: cwClient.Name,
 		}
 
-		if err := s.db.SaveClientMapping(mapping); err != nil {
+		if err := s.db.SaveClientMapping(ctx, mapping); err != nil {
 			return fmt.Errorf("failed to save mapping for %s: %w", slideClient.Name, err)
 		}
 
@@ -57,8 +58,8 @@ func (s *Service) MapClients(slideClients []models.SlideClient, cwClients []mode
 	return nil
 }
 
-func (s *Service) GetConnectWiseClientID(slideClientID string) (int, error) {
-	mapping, err := s.db.GetClientMapping(slideClientID)
+func (s *Service) GetConnectWiseClientID(ctx context.Context, slideClientID string) (int, error) {
+	mapping, err := s.db.GetClientMapping(ctx, slideClientID)
 	if err != nil {
 		return 0, err
 	}
@@ -70,28 +71,44 @@ func (s *Service) GetConnectWiseClientID(slideClientID string) (int, error) {
 	return mapping.ConnectWiseID, nil
 }
 
-func (s *Service) SaveAlertTicketMapping(alertID string, ticketID int) error {
+// SaveAlertTicketMapping records alert's ticket. clientID is the Slide client resolved
+// from the alert's device, which differs from the alert's own account ID for an MSP.
+// correlationKey is empty unless the alert is part of a correlated incident other
+// alerts may join.
+func (s *Service) SaveAlertTicketMapping(ctx context.Context, alert *models.SlideAlert, ticketID int, clientID, correlationKey string) error {
 	mapping := &models.AlertTicketMapping{
-		AlertID:  alertID,
-		TicketID: ticketID,
+		AlertID:        alert.ID,
+		TicketID:       ticketID,
+		AgentID:        alert.AgentID,
+		AgentName:      alert.GetParsedAgentName(),
+		ClientID:       clientID,
+		AlertType:      alert.Type,
+		CorrelationKey: correlationKey,
 	}
-	return s.db.SaveAlertTicketMapping(mapping)
+	if mapping.AgentName == "" {
+		mapping.AgentName = alert.AgentID
+	}
+	if !alert.Timestamp.IsZero() {
+		raisedAt := alert.Timestamp
+		mapping.AlertCreatedAt = &raisedAt
+	}
+	return s.db.SaveAlertTicketMapping(ctx, mapping)
 }
 
-func (s *Service) GetAlertTicketMapping(alertID string) (*models.AlertTicketMapping, error) {
-	return s.db.GetAlertTicketMapping(alertID)
+func (s *Service) GetAlertTicketMapping(ctx context.Context, alertID string) (*models.AlertTicketMapping, error) {
+	return s.db.GetAlertTicketMapping(ctx, alertID)
 }
 
-func (s *Service) CloseAlertTicketMapping(alertID string) error {
-	return s.db.CloseAlertTicketMapping(alertID)
+func (s *Service) CloseAlertTicketMapping(ctx context.Context, alertID string) error {
+	return s.db.CloseAlertTicketMapping(ctx, alertID)
 }
 
-func (s *Service) GetClientMapping(slideClientID string) (*models.ClientMapping, error) {
-	return s.db.GetClientMapping(slideClientID)
+func (s *Service) GetClientMapping(ctx context.Context, slideClientID string) (*models.ClientMapping, error) {
+	return s.db.GetClientMapping(ctx, slideClientID)
 }
 
-func (s *Service) SaveClientMapping(mapping *models.ClientMapping) error {
-	return s.db.SaveClientMapping(mapping)
+func (s *Service) SaveClientMapping(ctx context.Context, mapping *models.ClientMapping) error {
+	return s.db.SaveClientMapping(ctx, mapping)
 }
 
 func (s *Service) findMatchingClient(slideClient models.SlideClient, cwClients []models.ConnectWiseClient) *models.ConnectWiseClient {
@@ -2,12 +2,16 @@ package slide
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
+	"slide-cw-integration/internal/httpclient"
 	"slide-cw-integration/pkg/models"
 )
 
@@ -17,20 +21,49 @@ type Client struct {
 	httpClient *http.Client
 }
 
-type DeviceResponse struct {
-	Data []models.SlideDevice `json:"data"`
+// requestTimeout is the overall budget for one API call, including retries
+const requestTimeout = 2 * time.Minute
+
+// pageLimit is the largest page size the Slide API accepts for list endpoints
+const pageLimit = 50
+
+// Pagination is the paging metadata returned alongside every Slide list response
+type Pagination struct {
+	Total      int  `json:"total"`
+	Offset     int  `json:"offset"`
+	NextOffset *int `json:"next_offset"`
 }
 
-type ClientResponse struct {
-	Data []models.SlideClient `json:"data"`
+// ListResponse is the envelope used by Slide list endpoints
+type ListResponse[T any] struct {
+	Data       []T        `json:"data"`
+	Pagination Pagination `json:"pagination"`
 }
 
-type AlertResponse struct {
-	Data []models.SlideAlert `json:"data"`
+// AlertListOptions narrows the alerts returned by ListAlerts.
+// Zero values mean "no filter".
+type AlertListOptions struct {
+	Resolved     *bool
+	CreatedAfter time.Time
+	ClientID     string
+	DeviceID     string
 }
 
-type BackupResponse struct {
-	Data []models.SlideBackup `json:"data"`
+// BackupListOptions narrows the backups returned by ListBackups.
+// Zero values mean "no filter".
+type BackupListOptions struct {
+	AgentID      string
+	DeviceID     string
+	ClientID     string
+	StartedAfter time.Time
+}
+
+// ReplicationListOptions narrows the replications returned by ListReplications.
+// Zero values mean "no filter".
+type ReplicationListOptions struct {
+	DeviceID     string
+	AgentID      string
+	StartedAfter time.Time
 }
 
 func NewClient(baseURL, apiKey string) *Client {
@@ -41,45 +74,130 @@ func NewClient(baseURL, apiKey string) *Client {
 	return &Client{
 		baseURL: baseURL,
 		apiKey:  apiKey,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient: httpclient.New(httpclient.DefaultConfig("Slide"), requestTimeout),
 	}
 }
 
-func (c *Client) GetDevices() ([]models.SlideDevice, error) {
-	var response DeviceResponse
-	if err := c.makeRequest("GET", "/v1/device", nil, &response); err != nil {
+func (c *Client) GetDevices(ctx context.Context) ([]models.SlideDevice, error) {
+	devices, err := listAll[models.SlideDevice](ctx, c, "/v1/device", nil)
+	if err != nil {
 		return nil, fmt.Errorf("failed to get devices: %w", err)
 	}
-	return response.Data, nil
+	return devices, nil
 }
 
-func (c *Client) GetClients() ([]models.SlideClient, error) {
-	var response ClientResponse
-	if err := c.makeRequest("GET", "/v1/client", nil, &response); err != nil {
+func (c *Client) GetClients(ctx context.Context) ([]models.SlideClient, error) {
+	clients, err := listAll[models.SlideClient](ctx, c, "/v1/client", nil)
+	if err != nil {
 		return nil, fmt.Errorf("failed to get clients: %w", err)
 	}
-	return response.Data, nil
+	return clients, nil
+}
+
+// GetAlerts returns every alert visible to the API key, resolved or not
+func (c *Client) GetAlerts(ctx context.Context) ([]models.SlideAlert, error) {
+	return c.ListAlerts(ctx, AlertListOptions{})
 }
 
-func (c *Client) GetAlerts() ([]models.SlideAlert, error) {
-	var response AlertResponse
-	if err := c.makeRequest("GET", "/v1/alert", nil, &response); err != nil {
+// ListAlerts returns all alerts matching opts, following pagination until exhausted
+func (c *Client) ListAlerts(ctx context.Context, opts AlertListOptions) ([]models.SlideAlert, error) {
+	query := url.Values{}
+	if opts.Resolved != nil {
+		query.Set("resolved", strconv.FormatBool(*opts.Resolved))
+	}
+	if !opts.CreatedAfter.IsZero() {
+		query.Set("created_after", opts.CreatedAfter.UTC().Format(time.RFC3339))
+	}
+	if opts.ClientID != "" {
+		query.Set("client_id", opts.ClientID)
+	}
+	if opts.DeviceID != "" {
+		query.Set("device_id", opts.DeviceID)
+	}
+
+	alerts, err := listAll[models.SlideAlert](ctx, c, "/v1/alert", query)
+	if err != nil {
 		return nil, fmt.Errorf("failed to get alerts: %w", err)
 	}
-	return response.Data, nil
+
+	// Not every filter is honoured server-side, so re-apply them here to be safe
+	filtered := alerts[:0]
+	for _, alert := range alerts {
+		if opts.Resolved != nil && alert.Resolved != *opts.Resolved {
+			continue
+		}
+		if !opts.CreatedAfter.IsZero() && !alert.Timestamp.After(opts.CreatedAfter) {
+			continue
+		}
+		if opts.ClientID != "" && alert.GetParsedClientID() != opts.ClientID {
+			continue
+		}
+		if opts.DeviceID != "" && alert.DeviceID != opts.DeviceID {
+			continue
+		}
+		filtered = append(filtered, alert)
+	}
+
+	return filtered, nil
 }
 
-func (c *Client) GetBackups() ([]models.SlideBackup, error) {
-	var response BackupResponse
-	if err := c.makeRequest("GET", "/v1/backup", nil, &response); err != nil {
+// GetAlert returns the current state of a single alert
+func (c *Client) GetAlert(ctx context.Context, alertID string) (*models.SlideAlert, error) {
+	endpoint := fmt.Sprintf("/v1/alert/%s", alertID)
+	var alert models.SlideAlert
+	if err := c.makeRequest(ctx, "GET", endpoint, nil, &alert); err != nil {
+		return nil, fmt.Errorf("failed to get alert %s: %w", alertID, err)
+	}
+	return &alert, nil
+}
+
+// GetBackups returns every backup visible to the API key
+func (c *Client) GetBackups(ctx context.Context) ([]models.SlideBackup, error) {
+	return c.ListBackups(ctx, BackupListOptions{})
+}
+
+// ListBackups returns all backups matching opts, following pagination until exhausted
+func (c *Client) ListBackups(ctx context.Context, opts BackupListOptions) ([]models.SlideBackup, error) {
+	query := url.Values{}
+	if opts.AgentID != "" {
+		query.Set("agent_id", opts.AgentID)
+	}
+	if opts.DeviceID != "" {
+		query.Set("device_id", opts.DeviceID)
+	}
+	if opts.ClientID != "" {
+		query.Set("client_id", opts.ClientID)
+	}
+	if !opts.StartedAfter.IsZero() {
+		query.Set("started_after", opts.StartedAfter.UTC().Format(time.RFC3339))
+	}
+
+	backups, err := listAll[models.SlideBackup](ctx, c, "/v1/backup", query)
+	if err != nil {
 		return nil, fmt.Errorf("failed to get backups: %w", err)
 	}
-	return response.Data, nil
+
+	filtered := backups[:0]
+	for _, backup := range backups {
+		if opts.AgentID != "" && backup.AgentID != opts.AgentID {
+			continue
+		}
+		if opts.DeviceID != "" && backup.DeviceID != opts.DeviceID {
+			continue
+		}
+		if opts.ClientID != "" && backup.ClientID != opts.ClientID {
+			continue
+		}
+		if !opts.StartedAfter.IsZero() && !backup.StartTime.After(opts.StartedAfter) {
+			continue
+		}
+		filtered = append(filtered, backup)
+	}
+
+	return filtered, nil
 }
 
-func (c *Client) CloseAlert(alertID string) error {
+func (c *Client) CloseAlert(ctx context.Context, alertID string) error {
 	// Try setting both status and resolved fields
 	payload := map[string]interface{}{
 		"status":   "resolved",
@@ -89,7 +207,7 @@ func (c *Client) CloseAlert(alertID string) error {
 	endpoint := fmt.Sprintf("/v1/alert/%s", alertID)
 
 	log.Printf("Closing alert %s with payload: %+v", alertID, payload)
-	err := c.makeRequest("PATCH", endpoint, payload, nil)
+	err := c.makeRequest(ctx, "PATCH", endpoint, payload, nil)
 	if err != nil {
 		log.Printf("Error closing alert %s: %v", alertID, err)
 		return err
@@ -98,16 +216,94 @@ func (c *Client) CloseAlert(alertID string) error {
 	return nil
 }
 
-func (c *Client) GetDevice(deviceID string) (*models.SlideDevice, error) {
+func (c *Client) GetDevice(ctx context.Context, deviceID string) (*models.SlideDevice, error) {
 	endpoint := fmt.Sprintf("/v1/device/%s", deviceID)
 	var device models.SlideDevice
-	if err := c.makeRequest("GET", endpoint, nil, &device); err != nil {
+	if err := c.makeRequest(ctx, "GET", endpoint, nil, &device); err != nil {
 		return nil, fmt.Errorf("failed to get device %s: %w", deviceID, err)
 	}
 	return &device, nil
 }
 
-func (c *Client) makeRequest(method, endpoint string, payload interface{}, result interface{}) error {
+// GetAgent returns a single protected agent, including when it last checked in
+func (c *Client) GetAgent(ctx context.Context, agentID string) (*models.SlideAgent, error) {
+	endpoint := fmt.Sprintf("/v1/agent/%s", agentID)
+	var agent models.SlideAgent
+	if err := c.makeRequest(ctx, "GET", endpoint, nil, &agent); err != nil {
+		return nil, fmt.Errorf("failed to get agent %s: %w", agentID, err)
+	}
+	return &agent, nil
+}
+
+// ListReplications returns all replications matching opts, following pagination until exhausted
+func (c *Client) ListReplications(ctx context.Context, opts ReplicationListOptions) ([]models.SlideReplication, error) {
+	query := url.Values{}
+	if opts.DeviceID != "" {
+		query.Set("device_id", opts.DeviceID)
+	}
+	if opts.AgentID != "" {
+		query.Set("agent_id", opts.AgentID)
+	}
+	if !opts.StartedAfter.IsZero() {
+		query.Set("started_after", opts.StartedAfter.UTC().Format(time.RFC3339))
+	}
+
+	replications, err := listAll[models.SlideReplication](ctx, c, "/v1/replication", query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get replications: %w", err)
+	}
+
+	filtered := replications[:0]
+	for _, replication := range replications {
+		if opts.DeviceID != "" && replication.DeviceID != opts.DeviceID {
+			continue
+		}
+		if opts.AgentID != "" && replication.AgentID != opts.AgentID {
+			continue
+		}
+		if !opts.StartedAfter.IsZero() && !replication.StartTime.After(opts.StartedAfter) {
+			continue
+		}
+		filtered = append(filtered, replication)
+	}
+
+	return filtered, nil
+}
+
+// listAll walks a Slide list endpoint page by page using the offset/next_offset
+// pagination metadata and returns the concatenated data
+func listAll[T any](ctx context.Context, c *Client, endpoint string, query url.Values) ([]T, error) {
+	if query == nil {
+		query = url.Values{}
+	}
+
+	var all []T
+	offset := 0
+
+	for {
+		query.Set("offset", strconv.Itoa(offset))
+		query.Set("limit", strconv.Itoa(pageLimit))
+
+		var page ListResponse[T]
+		if err := c.makeRequest(ctx, "GET", endpoint+"?"+query.Encode(), nil, &page); err != nil {
+			return nil, fmt.Errorf("offset %d: %w", offset, err)
+		}
+
+		all = append(all, page.Data...)
+
+		// Stop when the API says there is nothing left, or if it stops making progress
+		next := page.Pagination.NextOffset
+		if next == nil || *next <= offset || len(page.Data) == 0 {
+			break
+		}
+
+		offset = *next
+	}
+
+	return all, nil
+}
+
+func (c *Client) makeRequest(ctx context.Context, method, endpoint string, payload interface{}, result interface{}) error {
 	var body *bytes.Buffer
 	if payload != nil {
 		jsonData, err := json.Marshal(payload)
@@ -119,7 +315,16 @@ func (c *Client) makeRequest(method, endpoint string, payload interface{}, resul
 		body = &bytes.Buffer{}
 	}
 
-	req, err := http.NewRequest(method, c.baseURL+endpoint, body)
+	// Every call gets its own deadline on top of whatever the caller set
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+
+	if method == http.MethodPatch {
+		// Slide PATCHes only set absolute field values, so replaying them is safe
+		ctx = httpclient.WithIdempotent(ctx)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, body)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -134,7 +339,7 @@ func (c *Client) makeRequest(method, endpoint string, payload interface{}, resul
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+		return httpclient.NewAPIError(method, endpoint, resp)
 	}
 
 	if result != nil {
@@ -0,0 +1,488 @@
+// Package slidetest provides an in-process fake of the Slide API for
+// exercising the integration end to end without a real Slide tenant.
+package slidetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"slide-cw-integration/internal/slide"
+	"slide-cw-integration/pkg/models"
+)
+
+// APIKey is the bearer token the fake server accepts
+const APIKey = "slidetest-api-key"
+
+// defaultLimit mirrors the real API's page size when no limit is requested
+const defaultLimit = 50
+
+// Patch records a PATCH received for an alert
+type Patch struct {
+	AlertID    string
+	Body       map[string]interface{}
+	ReceivedAt time.Time
+}
+
+// Failure scripts an error response for matching requests
+type Failure struct {
+	// Method and Path select the requests to fail; Path matches as a prefix.
+	// An empty Method matches any method.
+	Method string
+	Path   string
+
+	Status     int
+	Body       string
+	RetryAfter string
+
+	// Times is how many matching requests fail before the route recovers
+	Times int
+}
+
+// Server is a fake Slide API. Seed it with clients, devices, agents, alerts,
+// backups and replications, point a slide.Client at URL, then inspect what the client did.
+type Server struct {
+	URL string
+
+	srv *httptest.Server
+
+	mu           sync.Mutex
+	clients      []models.SlideClient
+	devices      []models.SlideDevice
+	agents       []models.SlideAgent
+	alerts       []models.SlideAlert
+	backups      []models.SlideBackup
+	replications []models.SlideReplication
+	patches      []Patch
+	failures     []*Failure
+	requests     map[string]int
+}
+
+// NewServer starts a fake Slide API. Call Close when done.
+func NewServer() *Server {
+	s := &Server{requests: make(map[string]int)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/client", s.handleClients)
+	mux.HandleFunc("GET /v1/device", s.handleDevices)
+	mux.HandleFunc("GET /v1/device/{id}", s.handleDevice)
+	mux.HandleFunc("GET /v1/agent/{id}", s.handleAgent)
+	mux.HandleFunc("GET /v1/alert", s.handleAlerts)
+	mux.HandleFunc("GET /v1/alert/{id}", s.handleAlert)
+	mux.HandleFunc("PATCH /v1/alert/{id}", s.handlePatchAlert)
+	mux.HandleFunc("GET /v1/backup", s.handleBackups)
+	mux.HandleFunc("GET /v1/replication", s.handleReplications)
+
+	s.srv = httptest.NewServer(s.middleware(mux))
+	s.URL = s.srv.URL
+
+	return s
+}
+
+// Close shuts the server down
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client returns a slide.Client pointed at the fake server
+func (s *Server) Client() *slide.Client {
+	return slide.NewClient(s.URL, APIKey)
+}
+
+// AddClients seeds Slide clients
+func (s *Server) AddClients(clients ...models.SlideClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients = append(s.clients, clients...)
+}
+
+// AddDevices seeds devices
+func (s *Server) AddDevices(devices ...models.SlideDevice) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.devices = append(s.devices, devices...)
+}
+
+// SetDeviceStorage updates the storage a device reports as used out of its total
+func (s *Server) SetDeviceStorage(deviceID string, usedBytes, totalBytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.devices {
+		if s.devices[i].ID == deviceID {
+			s.devices[i].StorageUsedBytes = usedBytes
+			s.devices[i].StorageTotalBytes = totalBytes
+		}
+	}
+}
+
+// AddAgents seeds agents
+func (s *Server) AddAgents(agents ...models.SlideAgent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.agents = append(s.agents, agents...)
+}
+
+// CheckInAgent records that an agent was last seen at the given time
+func (s *Server) CheckInAgent(agentID string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.agents {
+		if s.agents[i].ID == agentID {
+			seen := at
+			s.agents[i].LastSeenAt = &seen
+		}
+	}
+}
+
+// AddAlerts seeds alerts
+func (s *Server) AddAlerts(alerts ...models.SlideAlert) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerts = append(s.alerts, alerts...)
+}
+
+// AddBackups seeds backups
+func (s *Server) AddBackups(backups ...models.SlideBackup) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.backups = append(s.backups, backups...)
+}
+
+// AddReplications seeds replications
+func (s *Server) AddReplications(replications ...models.SlideReplication) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replications = append(s.replications, replications...)
+}
+
+// Alert returns the current state of an alert
+func (s *Server) Alert(alertID string) (models.SlideAlert, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, alert := range s.alerts {
+		if alert.ID == alertID {
+			return alert, true
+		}
+	}
+	return models.SlideAlert{}, false
+}
+
+// DeliverAlertWebhook POSTs the current state of alertID to url as a signed Slide
+// webhook event and returns the response status code
+func (s *Server) DeliverAlertWebhook(url, secret, event, alertID string) (int, error) {
+	alert, ok := s.Alert(alertID)
+	if !ok {
+		return 0, fmt.Errorf("alert %s not found", alertID)
+	}
+
+	body, err := json.Marshal(slide.WebhookEvent{Event: event, Alert: alert})
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(slide.WebhookTimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(slide.WebhookSignatureHeader, slide.SignWebhook(secret, now, body))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+
+	return resp.StatusCode, nil
+}
+
+// Patches returns every alert PATCH received so far, in order
+func (s *Server) Patches() []Patch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Patch(nil), s.patches...)
+}
+
+// Fail scripts a failure for matching requests
+func (s *Server) Fail(failure Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if failure.Times <= 0 {
+		failure.Times = 1
+	}
+	s.failures = append(s.failures, &failure)
+}
+
+// Requests returns how many requests were received for method and path (without query)
+func (s *Server) Requests(method, path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[method+" "+path]
+}
+
+// middleware checks auth, counts requests and applies scripted failures
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests[r.Method+" "+r.URL.Path]++
+		failure := s.takeFailure(r)
+		s.mu.Unlock()
+
+		if failure != nil {
+			if failure.RetryAfter != "" {
+				w.Header().Set("Retry-After", failure.RetryAfter)
+			}
+			writeError(w, failure.Status, failure.Body)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer "+APIKey {
+			writeError(w, http.StatusUnauthorized, "invalid API key")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// takeFailure consumes the first scripted failure matching r. Callers hold s.mu.
+func (s *Server) takeFailure(r *http.Request) *Failure {
+	for i, failure := range s.failures {
+		if failure.Method != "" && failure.Method != r.Method {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, failure.Path) {
+			continue
+		}
+
+		failure.Times--
+		if failure.Times <= 0 {
+			s.failures = append(s.failures[:i], s.failures[i+1:]...)
+		}
+		return failure
+	}
+	return nil
+}
+
+func (s *Server) handleClients(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	clients := append([]models.SlideClient(nil), s.clients...)
+	s.mu.Unlock()
+
+	writePage(w, r, clients)
+}
+
+func (s *Server) handleDevices(w http.ResponseWriter, r *http.Request) {
+	clientID := r.URL.Query().Get("client_id")
+
+	s.mu.Lock()
+	var devices []models.SlideDevice
+	for _, device := range s.devices {
+		if clientID == "" || device.ClientID == clientID {
+			devices = append(devices, device)
+		}
+	}
+	s.mu.Unlock()
+
+	writePage(w, r, devices)
+}
+
+func (s *Server) handleDevice(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, device := range s.devices {
+		if device.ID == id {
+			writeJSON(w, http.StatusOK, device)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "device not found")
+}
+
+func (s *Server) handleAgent(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, agent := range s.agents {
+		if agent.ID == id {
+			writeJSON(w, http.StatusOK, agent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "agent not found")
+}
+
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var createdAfter time.Time
+	if value := query.Get("created_after"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid created_after")
+			return
+		}
+		createdAfter = parsed
+	}
+
+	s.mu.Lock()
+	var alerts []models.SlideAlert
+	for _, alert := range s.alerts {
+		if value := query.Get("resolved"); value != "" && strconv.FormatBool(alert.Resolved) != value {
+			continue
+		}
+		if value := query.Get("client_id"); value != "" && alert.GetParsedClientID() != value {
+			continue
+		}
+		if value := query.Get("device_id"); value != "" && alert.DeviceID != value {
+			continue
+		}
+		if !createdAfter.IsZero() && !alert.Timestamp.After(createdAfter) {
+			continue
+		}
+		alerts = append(alerts, alert)
+	}
+	s.mu.Unlock()
+
+	writePage(w, r, alerts)
+}
+
+func (s *Server) handleAlert(w http.ResponseWriter, r *http.Request) {
+	alert, ok := s.Alert(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "alert not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, alert)
+}
+
+func (s *Server) handlePatchAlert(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.alerts {
+		alert := &s.alerts[i]
+		if alert.ID != id {
+			continue
+		}
+
+		s.patches = append(s.patches, Patch{AlertID: id, Body: body, ReceivedAt: time.Now()})
+
+		if resolved, ok := body["resolved"].(bool); ok {
+			alert.Resolved = resolved
+		}
+		if status, ok := body["status"].(string); ok {
+			alert.Status = status
+		}
+
+		writeJSON(w, http.StatusOK, alert)
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "alert not found")
+}
+
+func (s *Server) handleBackups(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	var backups []models.SlideBackup
+	for _, backup := range s.backups {
+		if value := query.Get("agent_id"); value != "" && backup.AgentID != value {
+			continue
+		}
+		if value := query.Get("device_id"); value != "" && backup.DeviceID != value {
+			continue
+		}
+		if value := query.Get("client_id"); value != "" && backup.ClientID != value {
+			continue
+		}
+		backups = append(backups, backup)
+	}
+	s.mu.Unlock()
+
+	writePage(w, r, backups)
+}
+
+func (s *Server) handleReplications(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	s.mu.Lock()
+	var replications []models.SlideReplication
+	for _, replication := range s.replications {
+		if value := query.Get("device_id"); value != "" && replication.DeviceID != value {
+			continue
+		}
+		if value := query.Get("agent_id"); value != "" && replication.AgentID != value {
+			continue
+		}
+		replications = append(replications, replication)
+	}
+	s.mu.Unlock()
+
+	writePage(w, r, replications)
+}
+
+// writePage applies offset/limit paging and writes the Slide list envelope
+func writePage[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if offset < 0 || offset > len(items) {
+		offset = len(items)
+	}
+
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+
+	page := slide.ListResponse[T]{
+		Data: append([]T{}, items[offset:end]...),
+		Pagination: slide.Pagination{
+			Total:  len(items),
+			Offset: offset,
+		},
+	}
+	if end < len(items) {
+		next := end
+		page.Pagination.NextOffset = &next
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
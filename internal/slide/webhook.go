@@ -0,0 +1,84 @@
+package slide
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"slide-cw-integration/pkg/models"
+)
+
+const (
+	// WebhookSignatureHeader carries the hex HMAC-SHA256 of "<timestamp>.<body>",
+	// optionally prefixed with "sha256="
+	WebhookSignatureHeader = "X-Slide-Signature"
+
+	// WebhookTimestampHeader carries the Unix time the event was sent
+	WebhookTimestampHeader = "X-Slide-Timestamp"
+
+	// webhookTolerance is how far a webhook timestamp may be from our clock,
+	// limiting how long a captured delivery can be replayed
+	webhookTolerance = 5 * time.Minute
+)
+
+// WebhookEvent is an alert event pushed by Slide
+type WebhookEvent struct {
+	Event string            `json:"event"`
+	Alert models.SlideAlert `json:"alert"`
+}
+
+// SignWebhook returns the signature header value for body sent at timestamp
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParseWebhook verifies a webhook delivery against the shared secret and decodes it
+func ParseWebhook(secret string, body []byte, signature, timestamp string) (*WebhookEvent, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("no webhook secret configured")
+	}
+
+	if signature == "" || timestamp == "" {
+		return nil, fmt.Errorf("missing %s or %s header", WebhookSignatureHeader, WebhookTimestampHeader)
+	}
+
+	sentAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook timestamp %q", timestamp)
+	}
+
+	skew := time.Since(time.Unix(sentAt, 0))
+	if skew < -webhookTolerance || skew > webhookTolerance {
+		return nil, fmt.Errorf("webhook timestamp is %s away from local time", skew.Round(time.Second))
+	}
+
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook signature encoding")
+	}
+
+	expected, _ := hex.DecodeString(strings.TrimPrefix(SignWebhook(secret, time.Unix(sentAt, 0), body), "sha256="))
+	if !hmac.Equal(got, expected) {
+		return nil, fmt.Errorf("webhook signature mismatch")
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook: %w", err)
+	}
+
+	if event.Alert.ID == "" {
+		return nil, fmt.Errorf("webhook has no alert_id")
+	}
+
+	return &event, nil
+}
@@ -0,0 +1,294 @@
+package web
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"slide-cw-integration/internal/connectwise"
+	"slide-cw-integration/pkg/models"
+)
+
+const (
+	// callbackPath is where ConnectWise delivers ticket callbacks; a secret token follows it
+	callbackPath = "/api/connectwise/callback/"
+
+	// callbackTokenSetting stores the token so the callback URL survives restarts
+	callbackTokenSetting = "connectwise_callback_token"
+
+	// callbackReconcileInterval is how often the monitor still polls every open
+	// ticket once callbacks are registered, to catch missed deliveries
+	callbackReconcileInterval = time.Hour
+
+	// maxCallbackBody caps how much of a callback request we read
+	maxCallbackBody = 1 << 20
+
+	callbackProcessTimeout = 2 * time.Minute
+)
+
+// CallbackAPI is the part of ConnectWise needed to register and verify ticket callbacks
+type CallbackAPI interface {
+	EnsureTicketCallbacks(ctx context.Context, callbackURL string, boardIDs []int) ([]models.ConnectWiseCallback, error)
+	ParseCallback(ctx context.Context, body []byte, signature string) (*connectwise.CallbackPayload, error)
+}
+
+// TicketUpdateHandler processes ticket updates pushed by ConnectWise. *alerts.Monitor satisfies it.
+type TicketUpdateHandler interface {
+	HandleTicketUpdate(ctx context.Context, ticketID int) error
+	SetReconcileInterval(interval time.Duration)
+}
+
+var _ CallbackAPI = (*connectwise.Client)(nil)
+
+// callbackState tracks the ConnectWise callback subscription
+type callbackState struct {
+	publicURL string
+	api       CallbackAPI
+	handler   TicketUpdateHandler
+
+	mu           sync.Mutex
+	token        string
+	callbacks    []models.ConnectWiseCallback
+	registeredAt time.Time
+	lastError    string
+	lastReceived time.Time
+	received     int
+}
+
+// EnableConnectWiseCallbacks has the server receive ConnectWise ticket callbacks at
+// publicURL (this server's externally reachable base URL) and pass them to handler.
+// Registration happens when the server starts and whenever the ticketing config or a
+// routing rule is saved.
+func (s *Server) EnableConnectWiseCallbacks(publicURL string, api CallbackAPI, handler TicketUpdateHandler) {
+	s.callbacks = &callbackState{
+		publicURL: strings.TrimRight(publicURL, "/"),
+		api:       api,
+		handler:   handler,
+	}
+}
+
+// registerCallback (re)registers a callback subscription for every board tickets are
+// created on. Until it succeeds the monitor keeps polling every open ticket each cycle.
+func (s *Server) registerCallback(ctx context.Context) error {
+	cb := s.callbacks
+
+	err := func() error {
+		token, err := s.callbackToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		boardIDs, err := s.callbackBoards(ctx)
+		if err != nil {
+			return err
+		}
+
+		callbacks, err := cb.api.EnsureTicketCallbacks(ctx, cb.publicURL+callbackPath+token, boardIDs)
+		if err != nil {
+			return err
+		}
+
+		cb.mu.Lock()
+		cb.callbacks = callbacks
+		cb.registeredAt = time.Now()
+		cb.lastError = ""
+		cb.mu.Unlock()
+		return nil
+	}()
+
+	if err != nil {
+		cb.mu.Lock()
+		cb.callbacks = nil
+		cb.lastError = err.Error()
+		cb.mu.Unlock()
+
+		log.Printf("ConnectWise callbacks unavailable, polling tickets every cycle: %v", err)
+		cb.handler.SetReconcileInterval(0)
+		return err
+	}
+
+	log.Printf("ConnectWise callbacks active, reconciling open tickets every %s", callbackReconcileInterval)
+	cb.handler.SetReconcileInterval(callbackReconcileInterval)
+	return nil
+}
+
+// callbackBoards returns the boards tickets can be created on: the ticketing config's and
+// those enabled routing rules move tickets to
+func (s *Server) callbackBoards(ctx context.Context) ([]int, error) {
+	config, err := s.db.GetTicketingConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticketing configuration: %w", err)
+	}
+	if config == nil {
+		return nil, fmt.Errorf("no ticketing configuration - save one to register callbacks")
+	}
+
+	rules, err := s.db.GetRoutingRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routing rules: %w", err)
+	}
+
+	boardIDs := []int{config.BoardID}
+	seen := map[int]bool{config.BoardID: true}
+	for _, rule := range rules {
+		if !rule.Enabled || rule.Action != models.RouteTicket || rule.BoardID == 0 || seen[rule.BoardID] {
+			continue
+		}
+		seen[rule.BoardID] = true
+		boardIDs = append(boardIDs, rule.BoardID)
+	}
+	return boardIDs, nil
+}
+
+// callbackToken returns the secret callback URL token, creating it on first use
+func (s *Server) callbackToken(ctx context.Context) (string, error) {
+	cb := s.callbacks
+
+	cb.mu.Lock()
+	token := cb.token
+	cb.mu.Unlock()
+	if token != "" {
+		return token, nil
+	}
+
+	token, err := s.db.GetSetting(ctx, callbackTokenSetting)
+	if err != nil {
+		return "", fmt.Errorf("failed to load callback token: %w", err)
+	}
+
+	if token == "" {
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			return "", fmt.Errorf("failed to generate callback token: %w", err)
+		}
+		token = hex.EncodeToString(raw)
+
+		if err := s.db.SetSetting(ctx, callbackTokenSetting, token); err != nil {
+			return "", fmt.Errorf("failed to save callback token: %w", err)
+		}
+	}
+
+	cb.mu.Lock()
+	cb.token = token
+	cb.mu.Unlock()
+	return token, nil
+}
+
+// ConnectWise ticket callback
+func (s *Server) handleConnectWiseCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cb := s.callbacks
+	cb.mu.Lock()
+	expected := cb.token
+	cb.mu.Unlock()
+
+	token := strings.TrimPrefix(r.URL.Path, callbackPath)
+	if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCallbackBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	payload, err := cb.api.ParseCallback(r.Context(), body, r.Header.Get("x-content-signature"))
+	if err != nil {
+		log.Printf("Rejected ConnectWise callback: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	cb.mu.Lock()
+	cb.lastReceived = time.Now()
+	cb.received++
+	cb.mu.Unlock()
+
+	if strings.EqualFold(payload.Type, "ticket") && payload.ID != 0 {
+		// Answer ConnectWise right away; the ticket lookup happens in the background
+		go func(ticketID int) {
+			ctx, cancel := context.WithTimeout(context.Background(), callbackProcessTimeout)
+			defer cancel()
+
+			if err := cb.handler.HandleTicketUpdate(ctx, ticketID); err != nil {
+				log.Printf("Error handling ConnectWise callback for ticket %d: %v", ticketID, err)
+			}
+		}(payload.ID)
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// ConnectWise callback subscription status
+func (s *Server) handleCallbackStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if s.callbacks == nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{"enabled": false})
+		return
+	}
+
+	cb := s.callbacks
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	status := map[string]interface{}{
+		"enabled":    true,
+		"registered": len(cb.callbacks) > 0,
+		"publicUrl":  cb.publicURL,
+		"received":   cb.received,
+	}
+	if len(cb.callbacks) > 0 {
+		var callbackIDs, boardIDs []int
+		for _, callback := range cb.callbacks {
+			callbackIDs = append(callbackIDs, callback.ID)
+			boardIDs = append(boardIDs, callback.ObjectID)
+		}
+		status["callbackIds"] = callbackIDs
+		status["boardIds"] = boardIDs
+		status["registeredAt"] = cb.registeredAt
+	}
+	if cb.lastError != "" {
+		status["lastError"] = cb.lastError
+	}
+	if !cb.lastReceived.IsZero() {
+		status["lastReceived"] = cb.lastReceived
+	}
+
+	json.NewEncoder(w).Encode(status)
+}
+
+// Re-register the ConnectWise callback subscription
+func (s *Server) handleRegisterCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if s.callbacks == nil {
+		http.Error(w, "ConnectWise callbacks are not enabled (set CONNECTWISE_CALLBACK_URL)", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.registerCallback(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
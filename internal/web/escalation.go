@@ -0,0 +1,102 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"slide-cw-integration/pkg/models"
+)
+
+// Escalation steps, earliest first, with how many tickets each has fired on
+func (s *Server) handleEscalationSteps(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	steps, err := s.db.GetEscalationSteps(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	escalations, err := s.db.GetTicketEscalations(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	fired := make(map[int]int)
+	for _, e := range escalations {
+		fired[e.StepID]++
+	}
+
+	type stepStatus struct {
+		models.EscalationStep
+		TicketsEscalated int `json:"tickets_escalated"`
+	}
+
+	statuses := []stepStatus{}
+	for _, step := range steps {
+		statuses = append(statuses, stepStatus{
+			EscalationStep:   step,
+			TicketsEscalated: fired[step.ID],
+		})
+	}
+
+	json.NewEncoder(w).Encode(statuses)
+}
+
+// Create or update an escalation step
+func (s *Server) handleSaveEscalationStep(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var step models.EscalationStep
+	if err := json.NewDecoder(r.Body).Decode(&step); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	step.Name = strings.TrimSpace(step.Name)
+	step.AlertType = strings.TrimSpace(step.AlertType)
+	step.Note = strings.TrimSpace(step.Note)
+	if step.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if err := step.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.SaveEscalationStep(r.Context(), &step); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Delete an escalation step
+func (s *Server) handleDeleteEscalationStep(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID int `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.DeleteEscalationStep(r.Context(), req.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
@@ -0,0 +1,130 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"slide-cw-integration/pkg/models"
+)
+
+// Maintenance windows, with whether each is active now and how many alerts it has held
+func (s *Server) handleMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	windows, err := s.db.GetMaintenanceWindows(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type windowStatus struct {
+		models.MaintenanceWindow
+		Active         bool `json:"active"`
+		AlertsRecorded int  `json:"alerts_recorded"`
+	}
+
+	now := time.Now()
+	statuses := []windowStatus{}
+	for _, window := range windows {
+		active, _ := window.ActiveAt(now)
+		recorded, err := s.db.GetMaintenanceAlerts(r.Context(), window.ID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		statuses = append(statuses, windowStatus{
+			MaintenanceWindow: window,
+			Active:            active,
+			AlertsRecorded:    len(recorded),
+		})
+	}
+
+	json.NewEncoder(w).Encode(statuses)
+}
+
+// Create or update a maintenance window
+func (s *Server) handleSaveMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var window models.MaintenanceWindow
+	if err := json.NewDecoder(r.Body).Decode(&window); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	window.Name = strings.TrimSpace(window.Name)
+	window.ScopeID = strings.TrimSpace(window.ScopeID)
+	if window.Timezone == "" {
+		window.Timezone = "UTC"
+	}
+	if window.Recurrence == "" {
+		window.Recurrence = models.RecurOnce
+	}
+	if window.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if err := window.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.SaveMaintenanceWindow(r.Context(), &window); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Delete a maintenance window
+func (s *Server) handleDeleteMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID int `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.DeleteMaintenanceWindow(r.Context(), req.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Alerts a maintenance window held back from ticketing
+func (s *Server) handleMaintenanceAlerts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	windowID, err := strconv.Atoi(r.URL.Query().Get("windowId"))
+	if err != nil {
+		http.Error(w, "windowId is required", http.StatusBadRequest)
+		return
+	}
+
+	alerts, err := s.db.GetMaintenanceAlerts(r.Context(), windowID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if alerts == nil {
+		alerts = []models.MaintenanceAlert{}
+	}
+	json.NewEncoder(w).Encode(alerts)
+}
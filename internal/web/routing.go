@@ -0,0 +1,162 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"slide-cw-integration/pkg/models"
+)
+
+// Routing rules, in the order they are tried
+func (s *Server) handleRoutingRules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rules, err := s.db.GetRoutingRules(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if rules == nil {
+		rules = []models.RoutingRule{}
+	}
+	json.NewEncoder(w).Encode(rules)
+}
+
+// Create or update a routing rule
+func (s *Server) handleSaveRoutingRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var rule models.RoutingRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		http.Error(w, "name is required", http.StatusBadRequest)
+		return
+	}
+	if err := rule.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.SaveRoutingRule(r.Context(), &rule); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// A rule may move tickets to a board without a callback subscription yet
+	if s.callbacks != nil {
+		go s.registerCallback(context.Background())
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Delete a routing rule
+func (s *Server) handleDeleteRoutingRule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID int `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.DeleteRoutingRule(r.Context(), req.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if s.callbacks != nil {
+		go s.registerCallback(context.Background())
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Change the order routing rules are tried in
+func (s *Server) handleReorderRoutingRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		IDs []int `json:"ids"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.ReorderRoutingRules(r.Context(), req.IDs); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Show which rule an alert with the given details would match, and the ticket settings it would get
+func (s *Server) handleTestRoutingRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var facts models.RoutingFacts
+	if err := json.NewDecoder(r.Body).Decode(&facts); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The monitor matches on the company the client is mapped to
+	if facts.CompanyID == 0 && facts.ClientID != "" {
+		mapping, err := s.mappingService.GetClientMapping(r.Context(), facts.ClientID)
+		if err == nil && mapping != nil {
+			facts.CompanyID = mapping.ConnectWiseID
+		}
+	}
+
+	rules, err := s.db.GetRoutingRules(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	config, err := s.db.GetTicketingConfig(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rule := models.MatchRoutingRule(rules, facts)
+	if config != nil && rule != nil {
+		routed := rule.Apply(*config)
+		config = &routed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"facts":   facts,
+		"rule":    rule,
+		"ignored": rule != nil && rule.Action == models.RouteIgnore,
+		"config":  config,
+	})
+}
@@ -1,6 +1,7 @@
 package web
 
 import (
+	"context"
 	"embed"
 	"encoding/json"
 	"fmt"
@@ -21,12 +22,39 @@ import (
 //go:embed static
 var staticFiles embed.FS
 
+// SlideAPI is the subset of the Slide API the web server depends on
+type SlideAPI interface {
+	GetAlerts(ctx context.Context) ([]models.SlideAlert, error)
+	GetClients(ctx context.Context) ([]models.SlideClient, error)
+	GetDevices(ctx context.Context) ([]models.SlideDevice, error)
+	CloseAlert(ctx context.Context, alertID string) error
+}
+
+// PSA is the subset of the ticketing system the web server depends on
+type PSA interface {
+	GetClients(ctx context.Context) ([]models.ConnectWiseClient, error)
+	GetBoards(ctx context.Context) ([]models.ConnectWiseBoard, error)
+	GetStatuses(ctx context.Context, boardID int) ([]models.ConnectWiseStatus, error)
+	GetPriorities(ctx context.Context) ([]models.ConnectWisePriority, error)
+	GetTypes(ctx context.Context, boardID int) ([]models.ConnectWiseType, error)
+	GetMembers(ctx context.Context) ([]models.ConnectWiseMember, error)
+	GetTicket(ctx context.Context, ticketID int) (*models.ConnectWiseTicket, error)
+}
+
+var (
+	_ SlideAPI = (*slide.Client)(nil)
+	_ PSA      = (*connectwise.Client)(nil)
+)
+
 type Server struct {
-	slideClient    *slide.Client
-	cwClient       *connectwise.Client
+	slideClient    SlideAPI
+	cwClient       PSA
 	mappingService *mapping.Service
 	db             *database.DB
 	port           string
+	callbacks      *callbackState
+	webhooks       *webhookState
+	instanceID     string
 }
 
 // matchDeviceToClient 
@@ -81,7 +109,7 @@ func matchDeviceToClient(deviceName string, slideClients []models.SlideClient) *
 	return nil
 }
 
-func NewServer(slideClient *slide.Client, cwClient *connectwise.Client, mappingService *mapping.Service, db *database.DB, port string) *Server {
+func NewServer(slideClient SlideAPI, cwClient PSA, mappingService *mapping.Service, db *database.DB, port string) *Server {
 	if port == "" {
 		port = "8080"
 	}
@@ -94,6 +122,11 @@ func NewServer(slideClient *slide.Client, cwClient *connectwise.Client, mappingS
 	}
 }
 
+// SetInstanceID tells the server which lease holder is this process, for the monitor status
+func (s *Server) SetInstanceID(id string) {
+	s.instanceID = id
+}
+
 func (s *Server) Start() error {
 	// Serve static files
 	staticFS, err := fs.Sub(staticFiles, "static")
@@ -105,6 +138,8 @@ func (s *Server) Start() error {
 	// API routes
 	http.HandleFunc("/api/health", s.handleHealth)
 	http.HandleFunc("/api/dashboard", s.handleDashboard)
+	http.HandleFunc("/api/monitor/leases", s.handleLeases)
+	http.HandleFunc("/api/monitor/circuit-breaker/reset", s.handleResetCircuitBreaker)
 
 	// Slide clients
 	http.HandleFunc("/api/slide/clients", s.handleSlideClients)
@@ -116,16 +151,25 @@ func (s *Server) Start() error {
 	http.HandleFunc("/api/connectwise/priorities", s.handleConnectWisePriorities)
 	http.HandleFunc("/api/connectwise/types", s.handleConnectWiseTypes)
 	http.HandleFunc("/api/connectwise/members", s.handleConnectWiseMembers)
+	http.HandleFunc("/api/connectwise/callbacks", s.handleCallbackStatus)
+	http.HandleFunc("/api/connectwise/callbacks/register", s.handleRegisterCallback)
 
 	// Mappings
 	http.HandleFunc("/api/mappings", s.handleMappings)
 	http.HandleFunc("/api/mappings/create", s.handleCreateMapping)
 	http.HandleFunc("/api/mappings/delete", s.handleDeleteMapping)
+	http.HandleFunc("/api/mappings/sync-policy", s.handleSetClientSyncPolicy)
 	http.HandleFunc("/api/mappings/auto", s.handleAutoMap)
 
 	// Ticketing config
 	http.HandleFunc("/api/ticketing/config", s.handleTicketingConfig)
 	http.HandleFunc("/api/ticketing/config/save", s.handleSaveTicketingConfig)
+	http.HandleFunc("/api/ticketing/resolved-statuses", s.handleResolvedStatuses)
+	http.HandleFunc("/api/ticketing/resolved-statuses/save", s.handleSaveResolvedStatus)
+	http.HandleFunc("/api/ticketing/resolved-statuses/delete", s.handleDeleteResolvedStatus)
+	http.HandleFunc("/api/ticketing/alert-policies", s.handleAlertTypePolicies)
+	http.HandleFunc("/api/ticketing/alert-policies/save", s.handleSaveAlertTypePolicy)
+	http.HandleFunc("/api/ticketing/alert-policies/delete", s.handleDeleteAlertTypePolicy)
 
 	// Alerts
 	http.HandleFunc("/api/alerts", s.handleAlerts)
@@ -135,6 +179,42 @@ func (s *Server) Start() error {
 	http.HandleFunc("/api/tickets/mappings", s.handleTicketMappings)
 	http.HandleFunc("/api/admin/reset-mapping", s.handleResetMapping)
 
+	// Routing rules
+	http.HandleFunc("/api/routing/rules", s.handleRoutingRules)
+	http.HandleFunc("/api/routing/rules/save", s.handleSaveRoutingRule)
+	http.HandleFunc("/api/routing/rules/delete", s.handleDeleteRoutingRule)
+	http.HandleFunc("/api/routing/rules/reorder", s.handleReorderRoutingRules)
+	http.HandleFunc("/api/routing/test", s.handleTestRoutingRules)
+
+	// Escalation steps
+	http.HandleFunc("/api/escalations", s.handleEscalationSteps)
+	http.HandleFunc("/api/escalations/save", s.handleSaveEscalationStep)
+	http.HandleFunc("/api/escalations/delete", s.handleDeleteEscalationStep)
+
+	// Maintenance windows
+	http.HandleFunc("/api/maintenance", s.handleMaintenanceWindows)
+	http.HandleFunc("/api/maintenance/save", s.handleSaveMaintenanceWindow)
+	http.HandleFunc("/api/maintenance/delete", s.handleDeleteMaintenanceWindow)
+	http.HandleFunc("/api/maintenance/alerts", s.handleMaintenanceAlerts)
+
+	// Retry queue
+	http.HandleFunc("/api/queue", s.handleWorkQueue)
+	http.HandleFunc("/api/queue/retry", s.handleRetryWorkItem)
+	http.HandleFunc("/api/queue/discard", s.handleDiscardWorkItem)
+
+	// Slide webhooks
+	http.HandleFunc("/api/webhooks/slide/status", s.handleSlideWebhookStatus)
+	if s.webhooks != nil {
+		http.HandleFunc(slideWebhookPath, s.handleSlideWebhook)
+	}
+
+	if s.callbacks != nil {
+		http.HandleFunc(callbackPath, s.handleConnectWiseCallback)
+
+		// Register in the background - a slow or unreachable ConnectWise shouldn't delay the UI
+		go s.registerCallback(context.Background())
+	}
+
 	log.Printf("Web UI server starting on http://localhost:%s", s.port)
 	return http.ListenAndServe(":"+s.port, nil)
 }
@@ -148,7 +228,7 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	alerts, _ := s.slideClient.GetAlerts()
+	alerts, _ := s.slideClient.GetAlerts(r.Context())
 	unresolvedCount := 0
 	for _, alert := range alerts {
 		if !alert.Resolved {
@@ -157,10 +237,10 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get mapping count
-	slideClients, _ := s.slideClient.GetClients()
+	slideClients, _ := s.slideClient.GetClients(r.Context())
 	mappedCount := 0
 	for _, client := range slideClients {
-		if mapping, err := s.mappingService.GetClientMapping(client.ID); err == nil && mapping != nil {
+		if mapping, err := s.mappingService.GetClientMapping(r.Context(), client.ID); err == nil && mapping != nil {
 			mappedCount++
 		}
 	}
@@ -168,14 +248,67 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	// Get ticket mapping count
 	query := "SELECT COUNT(*) FROM alert_ticket_mappings WHERE closed_at IS NULL"
 	var openTickets int
-	s.db.GetConn().QueryRow(query).Scan(&openTickets)
+	s.db.GetConn().QueryRowContext(r.Context(), query).Scan(&openTickets)
+
+	trippedAt, err := s.db.GetCircuitBreakerTrippedAt(r.Context())
+	if err != nil {
+		log.Printf("Warning: failed to read circuit breaker state: %v", err)
+	}
 
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"unresolvedAlerts": unresolvedCount,
-		"totalAlerts":      len(alerts),
-		"mappedClients":    mappedCount,
-		"totalClients":     len(slideClients),
-		"openTickets":      openTickets,
+		"unresolvedAlerts":      unresolvedCount,
+		"totalAlerts":           len(alerts),
+		"mappedClients":         mappedCount,
+		"totalClients":          len(slideClients),
+		"openTickets":           openTickets,
+		"circuitBreakerTripped": trippedAt,
+	})
+}
+
+// Reset the alert storm circuit breaker so ticket creation resumes on the next cycle
+func (s *Server) handleResetCircuitBreaker(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := s.db.ResetCircuitBreaker(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("Circuit breaker reset from the web UI; ticket creation resumes on the next cycle")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Monitor leases - which instance is processing alerts
+func (s *Server) handleLeases(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	leases, err := s.db.GetLeases(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	type leaseStatus struct {
+		models.Lease
+		Expired      bool `json:"expired"`
+		ThisInstance bool `json:"this_instance"`
+	}
+
+	statuses := []leaseStatus{}
+	for _, lease := range leases {
+		statuses = append(statuses, leaseStatus{
+			Lease:        lease,
+			Expired:      time.Now().After(lease.ExpiresAt),
+			ThisInstance: s.instanceID != "" && lease.Holder == s.instanceID,
+		})
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"instance_id": s.instanceID,
+		"leases":      statuses,
 	})
 }
 
@@ -183,7 +316,7 @@ func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleSlideClients(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	clients, err := s.slideClient.GetClients()
+	clients, err := s.slideClient.GetClients(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -196,7 +329,7 @@ func (s *Server) handleSlideClients(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleConnectWiseClients(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	clients, err := s.cwClient.GetClients()
+	clients, err := s.cwClient.GetClients(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -209,7 +342,7 @@ func (s *Server) handleConnectWiseClients(w http.ResponseWriter, r *http.Request
 func (s *Server) handleConnectWiseBoards(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	boards, err := s.cwClient.GetBoards()
+	boards, err := s.cwClient.GetBoards(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -229,7 +362,7 @@ func (s *Server) handleConnectWiseStatuses(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	statuses, err := s.cwClient.GetStatuses(boardID)
+	statuses, err := s.cwClient.GetStatuses(r.Context(), boardID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -242,7 +375,7 @@ func (s *Server) handleConnectWiseStatuses(w http.ResponseWriter, r *http.Reques
 func (s *Server) handleConnectWisePriorities(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	priorities, err := s.cwClient.GetPriorities()
+	priorities, err := s.cwClient.GetPriorities(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -262,7 +395,7 @@ func (s *Server) handleConnectWiseTypes(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	types, err := s.cwClient.GetTypes(boardID)
+	types, err := s.cwClient.GetTypes(r.Context(), boardID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -275,7 +408,7 @@ func (s *Server) handleConnectWiseTypes(w http.ResponseWriter, r *http.Request)
 func (s *Server) handleConnectWiseMembers(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	members, err := s.cwClient.GetMembers()
+	members, err := s.cwClient.GetMembers(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -288,7 +421,7 @@ func (s *Server) handleConnectWiseMembers(w http.ResponseWriter, r *http.Request
 func (s *Server) handleMappings(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	slideClients, err := s.slideClient.GetClients()
+	slideClients, err := s.slideClient.GetClients(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -296,7 +429,7 @@ func (s *Server) handleMappings(w http.ResponseWriter, r *http.Request) {
 
 	var mappings []map[string]interface{}
 	for _, client := range slideClients {
-		mapping, err := s.mappingService.GetClientMapping(client.ID)
+		mapping, err := s.mappingService.GetClientMapping(r.Context(), client.ID)
 		result := map[string]interface{}{
 			"slideClientId":   client.ID,
 			"slideClientName": client.Name,
@@ -307,6 +440,7 @@ func (s *Server) handleMappings(w http.ResponseWriter, r *http.Request) {
 			result["mapped"] = true
 			result["connectWiseId"] = mapping.ConnectWiseID
 			result["connectWiseName"] = mapping.ConnectWiseName
+			result["syncPolicy"] = mapping.SyncPolicy
 		}
 
 		mappings = append(mappings, result)
@@ -341,7 +475,7 @@ func (s *Server) handleCreateMapping(w http.ResponseWriter, r *http.Request) {
 		ConnectWiseName: req.ConnectWiseName,
 	}
 
-	if err := s.mappingService.SaveClientMapping(mapping); err != nil {
+	if err := s.mappingService.SaveClientMapping(r.Context(), mapping); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -366,7 +500,7 @@ func (s *Server) handleDeleteMapping(w http.ResponseWriter, r *http.Request) {
 	}
 
 	query := "DELETE FROM client_mappings WHERE slide_client_id = ?"
-	_, err := s.db.GetConn().Exec(query, req.SlideClientID)
+	_, err := s.db.GetConn().ExecContext(r.Context(), query, req.SlideClientID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -375,6 +509,35 @@ func (s *Server) handleDeleteMapping(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 }
 
+// Override the global sync policy for one mapped client; an empty policy clears the override
+func (s *Server) handleSetClientSyncPolicy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		SlideClientID string            `json:"slideClientId"`
+		SyncPolicy    models.SyncPolicy `json:"syncPolicy"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.SyncPolicy != "" && !req.SyncPolicy.Valid() {
+		http.Error(w, fmt.Sprintf("unknown sync policy %q", req.SyncPolicy), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.SetClientSyncPolicy(r.Context(), req.SlideClientID, req.SyncPolicy); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
 // Auto-map clients
 func (s *Server) handleAutoMap(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
@@ -382,19 +545,19 @@ func (s *Server) handleAutoMap(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	slideClients, err := s.slideClient.GetClients()
+	slideClients, err := s.slideClient.GetClients(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	cwClients, err := s.cwClient.GetClients()
+	cwClients, err := s.cwClient.GetClients(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	if err := s.mappingService.MapClients(slideClients, cwClients); err != nil {
+	if err := s.mappingService.MapClients(r.Context(), slideClients, cwClients); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -406,7 +569,7 @@ func (s *Server) handleAutoMap(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleTicketingConfig(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	config, err := s.db.GetTicketingConfig()
+	config, err := s.db.GetTicketingConfig(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -433,8 +596,263 @@ func (s *Server) handleSaveTicketingConfig(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	switch config.CorrelationMode {
+	case "":
+		config.CorrelationMode = models.CorrelationOff
+	case models.CorrelationOff, models.CorrelationAgent, models.CorrelationDevice, models.CorrelationClient:
+	default:
+		http.Error(w, fmt.Sprintf("unknown correlation mode %q", config.CorrelationMode), http.StatusBadRequest)
+		return
+	}
+	if config.CorrelationWindowMinutes < 0 {
+		http.Error(w, "correlation window must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	switch config.ReopenMode {
+	case "":
+		config.ReopenMode = models.ReopenOff
+	case models.ReopenOff, models.ReopenTicket, models.ReopenLink:
+	default:
+		http.Error(w, fmt.Sprintf("unknown reopen mode %q", config.ReopenMode), http.StatusBadRequest)
+		return
+	}
+	if config.ReopenWindowDays < 0 {
+		http.Error(w, "reopen window must not be negative", http.StatusBadRequest)
+		return
+	}
+	if config.SyncPolicy == "" {
+		config.SyncPolicy = models.SyncTwoWay
+	} else if !config.SyncPolicy.Valid() {
+		http.Error(w, fmt.Sprintf("unknown sync policy %q", config.SyncPolicy), http.StatusBadRequest)
+		return
+	}
+	if config.StormClientLimit < 0 || config.StormTotalLimit < 0 {
+		http.Error(w, "alert storm limits must not be negative", http.StatusBadRequest)
+		return
+	}
+	if config.StorageResolvePercent < 0 || config.StorageResolvePercent > 100 {
+		http.Error(w, "storage resolve threshold must be between 0 and 100", http.StatusBadRequest)
+		return
+	}
+
 	config.UpdatedAt = time.Now()
-	if err := s.db.SaveTicketingConfig(&config); err != nil {
+	if err := s.db.SaveTicketingConfig(r.Context(), &config); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// The callback subscriptions follow the configured board
+	if s.callbacks != nil {
+		go s.registerCallback(context.Background())
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Per-board resolved statuses
+func (s *Server) handleResolvedStatuses(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	statuses, err := s.db.GetBoardResolvedStatuses(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if statuses == nil {
+		statuses = []models.BoardResolvedStatus{}
+	}
+	json.NewEncoder(w).Encode(statuses)
+}
+
+// Save a board's resolved status
+func (s *Server) handleSaveResolvedStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var status models.BoardResolvedStatus
+	if err := json.NewDecoder(r.Body).Decode(&status); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if status.BoardID == 0 || status.StatusID == 0 {
+		http.Error(w, "board_id and status_id are required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.SaveBoardResolvedStatus(r.Context(), &status); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Revert a board to its own closed status
+func (s *Server) handleDeleteResolvedStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		BoardID int `json:"board_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.DeleteBoardResolvedStatus(r.Context(), req.BoardID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Per-alert-type grace periods and flap suppression
+func (s *Server) handleAlertTypePolicies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	policies, err := s.db.GetAlertTypePolicies(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if policies == nil {
+		policies = []models.AlertTypePolicy{}
+	}
+	json.NewEncoder(w).Encode(policies)
+}
+
+// Save an alert type's policy
+func (s *Server) handleSaveAlertTypePolicy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var policy models.AlertTypePolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	policy.AlertType = strings.TrimSpace(policy.AlertType)
+	policy.VerifyStatus = strings.TrimSpace(policy.VerifyStatus)
+	if policy.AlertType == "" {
+		http.Error(w, "alert_type is required", http.StatusBadRequest)
+		return
+	}
+	if policy.GraceMinutes < 0 || policy.FlapThreshold < 0 || policy.FlapGraceMinutes < 0 {
+		http.Error(w, "minutes and thresholds must not be negative", http.StatusBadRequest)
+		return
+	}
+	if policy.FlapThreshold > 0 && policy.FlapWindowHours <= 0 {
+		http.Error(w, "flap_window_hours is required when flap_threshold is set", http.StatusBadRequest)
+		return
+	}
+	if policy.VerifyBackups < 0 || policy.VerifyHours < 0 {
+		http.Error(w, "backup verification settings must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.SaveAlertTypePolicy(r.Context(), &policy); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Remove an alert type's policy so it tickets immediately again
+func (s *Server) handleDeleteAlertTypePolicy(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		AlertType string `json:"alert_type"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.DeleteAlertTypePolicy(r.Context(), req.AlertType); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Retry queue
+func (s *Server) handleWorkQueue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	items, err := s.db.GetWorkItems(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if items == nil {
+		items = []models.WorkItem{}
+	}
+	json.NewEncoder(w).Encode(items)
+}
+
+// Retry a queued item now, with a fresh set of attempts
+func (s *Server) handleRetryWorkItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID int `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.RetryWorkItem(r.Context(), req.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Drop a queued item without retrying it; a discarded ticket creation leaves its alert unticketed
+func (s *Server) handleDiscardWorkItem(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		ID int `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.DiscardWorkItem(r.Context(), req.ID); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -446,21 +864,21 @@ func (s *Server) handleSaveTicketingConfig(w http.ResponseWriter, r *http.Reques
 func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	alerts, err := s.slideClient.GetAlerts()
+	alerts, err := s.slideClient.GetAlerts(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Get all devices to resolve device_id to client_id
-	devices, err := s.slideClient.GetDevices()
+	devices, err := s.slideClient.GetDevices(r.Context())
 	if err != nil {
 		log.Printf("Warning: failed to get devices for alert enrichment: %v", err)
 		devices = []models.SlideDevice{} // Continue with empty list
 	}
 
 	// Get all Slide clients
-	slideClients, err := s.slideClient.GetClients()
+	slideClients, err := s.slideClient.GetClients(r.Context())
 	if err != nil {
 		log.Printf("Warning: failed to get clients for alert enrichment: %v", err)
 		slideClients = []models.SlideClient{}
@@ -526,7 +944,7 @@ func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
 		// Get the mapped ConnectWise company name
 		var cwCompanyName string
 		if realClientID != "" {
-			mapping, _ := s.mappingService.GetClientMapping(realClientID)
+			mapping, _ := s.mappingService.GetClientMapping(r.Context(), realClientID)
 			if mapping != nil {
 				cwCompanyName = mapping.ConnectWiseName
 			}
@@ -552,7 +970,7 @@ func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Check if ticket exists
-		ticketMapping, _ := s.mappingService.GetAlertTicketMapping(alert.ID)
+		ticketMapping, _ := s.mappingService.GetAlertTicketMapping(r.Context(), alert.ID)
 		if ticketMapping != nil {
 			enriched["ticketId"] = ticketMapping.TicketID
 		}
@@ -579,7 +997,7 @@ func (s *Server) handleCloseAlert(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.slideClient.CloseAlert(req.AlertID); err != nil {
+	if err := s.slideClient.CloseAlert(r.Context(), req.AlertID); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -603,7 +1021,7 @@ func (s *Server) handleResetMapping(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Reset the closed_at timestamp to NULL so the monitoring loop will try again
-	_, err := s.db.GetConn().Exec("UPDATE alert_ticket_mappings SET closed_at = NULL WHERE alert_id = ?", req.AlertID)
+	_, err := s.db.GetConn().ExecContext(r.Context(), "UPDATE alert_ticket_mappings SET closed_at = NULL WHERE alert_id = ?", req.AlertID)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -617,8 +1035,8 @@ func (s *Server) handleResetMapping(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleTicketMappings(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	query := "SELECT alert_id, ticket_id, created_at, closed_at FROM alert_ticket_mappings ORDER BY created_at DESC LIMIT 100"
-	rows, err := s.db.GetConn().Query(query)
+	query := "SELECT alert_id, ticket_id, COALESCE(resolution, ''), verifying_since, created_at, closed_at FROM alert_ticket_mappings ORDER BY created_at DESC LIMIT 100"
+	rows, err := s.db.GetConn().QueryContext(r.Context(), query)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -629,10 +1047,12 @@ func (s *Server) handleTicketMappings(w http.ResponseWriter, r *http.Request) {
 	for rows.Next() {
 		var alertID string
 		var ticketID int
+		var resolution string
+		var verifyingSince *time.Time
 		var createdAt time.Time
 		var closedAt *time.Time
 
-		if err := rows.Scan(&alertID, &ticketID, &createdAt, &closedAt); err != nil {
+		if err := rows.Scan(&alertID, &ticketID, &resolution, &verifyingSince, &createdAt, &closedAt); err != nil {
 			continue
 		}
 
@@ -645,9 +1065,21 @@ func (s *Server) handleTicketMappings(w http.ResponseWriter, r *http.Request) {
 		if closedAt != nil {
 			mapping["closedAt"] = closedAt
 		}
+		if resolution != "" {
+			mapping["resolution"] = resolution
+		}
+		if verifyingSince != nil && closedAt == nil {
+			mapping["verifyingSince"] = verifyingSince
+		}
+
+		// The browser went away - don't keep hitting ConnectWise on its behalf
+		if r.Context().Err() != nil {
+			log.Printf("Client disconnected, abandoning ticket status lookups")
+			return
+		}
 
 		// Fetch real-time ticket status from ConnectWise
-		ticket, err := s.cwClient.GetTicket(ticketID)
+		ticket, err := s.cwClient.GetTicket(r.Context(), ticketID)
 		if err != nil {
 			log.Printf("Warning: failed to get ticket %d status: %v", ticketID, err)
 			mapping["ticketStatus"] = "Unknown"
@@ -655,7 +1087,7 @@ func (s *Server) handleTicketMappings(w http.ResponseWriter, r *http.Request) {
 		} else {
 			mapping["ticketStatus"] = ticket.Status.Name
 			mapping["ticketClosed"] = ticket.IsClosed()
-			mapping["ticketClosedFlag"] = ticket.Status.ClosedStatus
+			mapping["ticketClosedFlag"] = ticket.ClosedFlag || ticket.Status.ClosedStatus
 
 			// If ticket is closed in CW but not marked closed in our DB, flag it
 			if ticket.IsClosed() && closedAt == nil {
@@ -10,7 +10,13 @@ let state = {
     priorities: [],
     types: [],
     members: [],
-    config: {}
+    config: {},
+    resolvedStatuses: [],
+    alertPolicies: [],
+    workQueue: [],
+    maintenanceWindows: [],
+    routingRules: [],
+    escalationSteps: []
 };
 
 // Initialize app
@@ -19,13 +25,18 @@ document.addEventListener('DOMContentLoaded', () => {
     initModals();
     checkHealth();
     loadDashboard();
+    loadLeaseStatus();
+    document.getElementById('resetCircuitBreakerBtn').addEventListener('click', resetCircuitBreaker);
     initMappings();
     initTicketing();
     initAlerts();
     initTickets();
+    initMaintenance();
+    initRouting();
 
     // Auto-refresh dashboard every 30 seconds
     setInterval(loadDashboard, 30000);
+    setInterval(loadLeaseStatus, 30000);
 });
 
 // Tab navigation
@@ -47,6 +58,7 @@ function initTabs() {
             switch(tabName) {
                 case 'dashboard':
                     loadDashboard();
+                    loadLeaseStatus();
                     break;
                 case 'mappings':
                     loadMappings();
@@ -59,6 +71,13 @@ function initTabs() {
                     break;
                 case 'tickets':
                     loadTicketMappings();
+                    loadWorkQueue();
+                    break;
+                case 'maintenance':
+                    loadMaintenanceWindows();
+                    break;
+                case 'routing':
+                    loadRoutingRules();
                     break;
             }
         });
@@ -96,11 +115,59 @@ async function loadDashboard() {
         document.getElementById('mappedClients').textContent = data.mappedClients;
         document.getElementById('totalClients').textContent = `of ${data.totalClients} total`;
         document.getElementById('openTickets').textContent = data.openTickets;
+
+        const banner = document.getElementById('circuitBreakerBanner');
+        if (data.circuitBreakerTripped) {
+            document.getElementById('circuitBreakerTrippedAt').textContent = new Date(data.circuitBreakerTripped).toLocaleString();
+            banner.classList.add('active');
+        } else {
+            banner.classList.remove('active');
+        }
     } catch (error) {
         console.error('Error loading dashboard:', error);
     }
 }
 
+async function resetCircuitBreaker() {
+    if (!confirm('Resume ticket creation? Alerts held while the circuit breaker was tripped will be ticketed on the next check.')) return;
+
+    try {
+        const response = await fetch('/api/monitor/circuit-breaker/reset', { method: 'POST' });
+        if (!response.ok) {
+            showNotification('Failed to resume ticketing: ' + await response.text(), 'error');
+            return;
+        }
+        await loadDashboard();
+    } catch (error) {
+        showNotification('Error: ' + error.message, 'error');
+    }
+}
+
+async function loadLeaseStatus() {
+    const holderEl = document.getElementById('leaseHolder');
+    const renewedEl = document.getElementById('leaseRenewed');
+
+    try {
+        const response = await fetch('/api/monitor/leases');
+        const data = await response.json();
+        const lease = data.leases.find(l => l.name === 'alert_monitor');
+
+        if (!lease) {
+            holderEl.textContent = 'None';
+            renewedEl.textContent = 'No monitor is running';
+            return;
+        }
+
+        holderEl.textContent = lease.this_instance ? 'This instance' : lease.holder;
+        holderEl.title = lease.holder;
+        renewedEl.textContent = lease.expired
+            ? `Expired - last renewed ${new Date(lease.renewed_at).toLocaleString()}`
+            : `Renewed ${new Date(lease.renewed_at).toLocaleString()}`;
+    } catch (error) {
+        console.error('Error loading lease status:', error);
+    }
+}
+
 // Mappings
 function initMappings() {
     document.getElementById('autoMapBtn').addEventListener('click', autoMapClients);
@@ -154,6 +221,12 @@ function renderMappings(filter = '') {
                 ${mapping.mapped ? `<div class="mapping-subtitle">→ ${mapping.connectWiseName} (ID: ${mapping.connectWiseId})</div>` : ''}
             </div>
             <div class="mapping-actions">
+                ${mapping.mapped ? `
+                    <select class="sync-policy-select" title="Sync policy" onchange="setClientSyncPolicy('${mapping.slideClientId}', this.value)">
+                        ${syncPolicyOptions.map(([value, label]) =>
+                            `<option value="${value}" ${(mapping.syncPolicy || '') === value ? 'selected' : ''}>${label}</option>`
+                        ).join('')}
+                    </select>` : ''}
                 ${mapping.mapped ?
                     `<button class="btn btn-danger" onclick="deleteMapping('${mapping.slideClientId}')">🗑️ Delete</button>` :
                     `<button class="btn btn-primary" onclick="createMapping('${mapping.slideClientId}', '${escapeHtml(mapping.slideClientName)}')">➕ Map</button>`
@@ -163,6 +236,35 @@ function renderMappings(filter = '') {
     `).join('');
 }
 
+// Per-client overrides of the global sync policy
+const syncPolicyOptions = [
+    ['', 'Sync: global default'],
+    ['two_way', 'Sync: two-way'],
+    ['slide', 'Sync: Slide-authoritative'],
+    ['connectwise', 'Sync: ConnectWise-authoritative'],
+    ['require_backup', 'Sync: require successful backup']
+];
+
+async function setClientSyncPolicy(slideClientId, syncPolicy) {
+    try {
+        const response = await fetch('/api/mappings/sync-policy', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ slideClientId, syncPolicy })
+        });
+
+        if (response.ok) {
+            const mapping = state.mappings.find(m => m.slideClientId === slideClientId);
+            if (mapping) mapping.syncPolicy = syncPolicy;
+            showNotification('Sync policy saved', 'success');
+        } else {
+            showNotification('Failed to save sync policy: ' + await response.text(), 'error');
+        }
+    } catch (error) {
+        showNotification('Error: ' + error.message, 'error');
+    }
+}
+
 function filterMappings(e) {
     renderMappings(e.target.value);
 }
@@ -270,12 +372,27 @@ function initTicketing() {
         document.getElementById('technicianGroup').style.display = e.target.checked ? 'block' : 'none';
     });
 
+    document.getElementById('scheduleTech').addEventListener('change', (e) => {
+        document.getElementById('scheduleGroup').style.display = e.target.checked ? 'block' : 'none';
+    });
+
     form.addEventListener('submit', async (e) => {
         e.preventDefault();
         await saveTicketingConfig();
     });
 
     document.getElementById('previewTemplateBtn').addEventListener('click', previewTemplate);
+
+    document.getElementById('resolvedBoardSelect').addEventListener('change', async (e) => {
+        const boardId = parseInt(e.target.value);
+        if (boardId) {
+            await loadResolvedStatusOptions(boardId);
+        }
+    });
+    document.getElementById('saveResolvedStatusBtn').addEventListener('click', saveResolvedStatus);
+    document.getElementById('saveAlertPolicyBtn').addEventListener('click', saveAlertPolicy);
+    document.getElementById('saveEscalationBtn').addEventListener('click', saveEscalationStep);
+    document.getElementById('resetEscalationBtn').addEventListener('click', resetEscalationForm);
 }
 
 async function loadTicketingConfig() {
@@ -297,6 +414,9 @@ async function loadTicketingConfig() {
         boardSelect.innerHTML = '<option value="">Select a board...</option>' +
             state.boards.map(b => `<option value="${b.id}" data-name="${escapeHtml(b.name)}">${b.name}</option>`).join('');
 
+        const resolvedBoardSelect = document.getElementById('resolvedBoardSelect');
+        resolvedBoardSelect.innerHTML = boardSelect.innerHTML;
+
         // Populate priorities
         const prioritySelect = document.getElementById('prioritySelect');
         prioritySelect.innerHTML = '<option value="">Select a priority...</option>' +
@@ -333,7 +453,33 @@ async function loadTicketingConfig() {
             if (state.config.technician_id) {
                 document.getElementById('technicianSelect').value = state.config.technician_id;
             }
+            document.getElementById('assignOwner').checked = state.config.assign_owner;
+            document.getElementById('assignResource').checked = state.config.assign_resource;
+            document.getElementById('scheduleTech').checked = state.config.schedule_tech;
+            document.getElementById('scheduleGroup').style.display = state.config.schedule_tech ? 'block' : 'none';
         }
+
+        if (state.config.schedule_minutes) {
+            document.getElementById('scheduleMinutes').value = state.config.schedule_minutes;
+        }
+
+        document.getElementById('correlationMode').value = state.config.correlation_mode || 'off';
+        if (state.config.correlation_window_minutes) {
+            document.getElementById('correlationWindow').value = state.config.correlation_window_minutes;
+        }
+
+        document.getElementById('stormClientLimit').value = state.config.storm_client_limit || 0;
+        document.getElementById('stormTotalLimit').value = state.config.storm_total_limit || 0;
+        document.getElementById('storageResolvePercent').value = state.config.storage_resolve_percent || 80;
+        document.getElementById('reopenMode').value = state.config.reopen_mode || 'off';
+        document.getElementById('syncPolicy').value = state.config.sync_policy || 'two_way';
+        if (state.config.reopen_window_days) {
+            document.getElementById('reopenWindow').value = state.config.reopen_window_days;
+        }
+
+        await loadResolvedStatuses();
+        await loadAlertPolicies();
+        await loadEscalationSteps();
     } catch (error) {
         console.error('Error loading ticketing config:', error);
     }
@@ -381,12 +527,29 @@ async function saveTicketingConfig() {
         ticket_template: document.getElementById('ticketTemplate').value,
         auto_assign_tech: document.getElementById('autoAssignTech').checked,
         technician_id: null,
-        technician_name: ''
+        technician_name: '',
+        assign_owner: document.getElementById('assignOwner').checked,
+        assign_resource: document.getElementById('assignResource').checked,
+        schedule_tech: document.getElementById('scheduleTech').checked,
+        schedule_minutes: parseInt(document.getElementById('scheduleMinutes').value) || 60,
+        correlation_mode: document.getElementById('correlationMode').value,
+        correlation_window_minutes: parseInt(document.getElementById('correlationWindow').value) || 60,
+        storm_client_limit: parseInt(document.getElementById('stormClientLimit').value) || 0,
+        storm_total_limit: parseInt(document.getElementById('stormTotalLimit').value) || 0,
+        storage_resolve_percent: parseInt(document.getElementById('storageResolvePercent').value) || 80,
+        reopen_mode: document.getElementById('reopenMode').value,
+        sync_policy: document.getElementById('syncPolicy').value,
+        reopen_window_days: parseInt(document.getElementById('reopenWindow').value) || 7
     };
 
     if (config.auto_assign_tech && techSelect.value) {
         config.technician_id = parseInt(techSelect.value);
         config.technician_name = techSelect.options[techSelect.selectedIndex].dataset.name;
+
+        if (!config.assign_owner && !config.assign_resource && !config.schedule_tech) {
+            showConfigStatus('Choose at least one way to assign the technician', 'error');
+            return;
+        }
     }
 
     try {
@@ -406,6 +569,358 @@ async function saveTicketingConfig() {
     }
 }
 
+// Per-board resolved statuses
+async function loadResolvedStatuses() {
+    try {
+        const response = await fetch('/api/ticketing/resolved-statuses');
+        state.resolvedStatuses = await response.json();
+        renderResolvedStatuses();
+    } catch (error) {
+        console.error('Error loading resolved statuses:', error);
+    }
+}
+
+function renderResolvedStatuses() {
+    const container = document.getElementById('resolvedStatusList');
+
+    if (state.resolvedStatuses.length === 0) {
+        container.innerHTML = '<div class="empty-state"><p>No overrides - every board uses its own closed status</p></div>';
+        return;
+    }
+
+    container.innerHTML = state.resolvedStatuses.map(rs => `
+        <div class="mapping-item">
+            <div class="mapping-info">
+                <div class="mapping-title">${escapeHtml(rs.board_name)}</div>
+                <div class="mapping-subtitle">Resolved status: ${escapeHtml(rs.status_name)}</div>
+            </div>
+            <div class="mapping-actions">
+                <button class="btn btn-danger" onclick="deleteResolvedStatus(${rs.board_id})">Use Board Default</button>
+            </div>
+        </div>
+    `).join('');
+}
+
+async function loadResolvedStatusOptions(boardId) {
+    try {
+        const response = await fetch(`/api/connectwise/statuses?boardId=${boardId}`);
+        const statuses = await response.json();
+
+        const statusSelect = document.getElementById('resolvedStatusSelect');
+        statusSelect.innerHTML = '<option value="">Board default (closed status)</option>' +
+            statuses.map(s => `<option value="${s.id}" data-name="${escapeHtml(s.name)}">${escapeHtml(s.name)}${s.closedStatus ? ' (closed)' : ''}</option>`).join('');
+
+        const current = state.resolvedStatuses.find(rs => rs.board_id === boardId);
+        if (current) {
+            statusSelect.value = current.status_id;
+        }
+    } catch (error) {
+        console.error('Error loading resolved status options:', error);
+    }
+}
+
+async function saveResolvedStatus() {
+    const boardSelect = document.getElementById('resolvedBoardSelect');
+    const statusSelect = document.getElementById('resolvedStatusSelect');
+
+    const boardId = parseInt(boardSelect.value);
+    if (!boardId) {
+        showConfigStatus('Select a board first', 'error');
+        return;
+    }
+
+    // Picking the board default removes the override
+    if (!statusSelect.value) {
+        await deleteResolvedStatus(boardId);
+        return;
+    }
+
+    const resolvedStatus = {
+        board_id: boardId,
+        board_name: boardSelect.options[boardSelect.selectedIndex].dataset.name,
+        status_id: parseInt(statusSelect.value),
+        status_name: statusSelect.options[statusSelect.selectedIndex].dataset.name
+    };
+
+    try {
+        const response = await fetch('/api/ticketing/resolved-statuses/save', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(resolvedStatus)
+        });
+
+        if (response.ok) {
+            showConfigStatus('Resolved status saved', 'success');
+            await loadResolvedStatuses();
+        } else {
+            showConfigStatus('Failed to save resolved status', 'error');
+        }
+    } catch (error) {
+        showConfigStatus('Error: ' + error.message, 'error');
+    }
+}
+
+async function deleteResolvedStatus(boardId) {
+    try {
+        const response = await fetch('/api/ticketing/resolved-statuses/delete', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ board_id: boardId })
+        });
+
+        if (response.ok) {
+            showConfigStatus('Board will use its own closed status', 'success');
+            await loadResolvedStatuses();
+        } else {
+            showConfigStatus('Failed to remove resolved status', 'error');
+        }
+    } catch (error) {
+        showConfigStatus('Error: ' + error.message, 'error');
+    }
+}
+
+// Per-alert-type grace periods and flap suppression
+async function loadAlertPolicies() {
+    try {
+        const response = await fetch('/api/ticketing/alert-policies');
+        state.alertPolicies = await response.json();
+        renderAlertPolicies();
+    } catch (error) {
+        console.error('Error loading alert policies:', error);
+    }
+}
+
+function renderAlertPolicies() {
+    const container = document.getElementById('alertPolicyList');
+
+    if (state.alertPolicies.length === 0) {
+        container.innerHTML = '<div class="empty-state"><p>No policies - every alert is ticketed as soon as it is seen</p></div>';
+        return;
+    }
+
+    container.innerHTML = state.alertPolicies.map(p => `
+        <div class="mapping-item">
+            <div class="mapping-info">
+                <div class="mapping-title">${escapeHtml(p.alert_type)}</div>
+                <div class="mapping-subtitle">
+                    Grace period: ${p.grace_minutes} min
+                    ${p.flap_threshold > 0
+                        ? ` · Flapping after ${p.flap_threshold} alerts in ${p.flap_window_hours}h, then waits ${p.flap_grace_minutes} min`
+                        : ' · Flap suppression off'}
+                    ${p.verify_backups > 1 || p.verify_hours > 0
+                        ? ` · Closes after ${Math.max(p.verify_backups, 1)} successful backups over ${p.verify_hours}h (${escapeHtml(p.verify_status || 'Pending Verification')} meanwhile)`
+                        : ''}
+                </div>
+            </div>
+            <div class="mapping-actions">
+                <button class="btn btn-secondary" onclick="editAlertPolicy('${escapeHtml(p.alert_type)}')">Edit</button>
+                <button class="btn btn-danger" onclick="deleteAlertPolicy('${escapeHtml(p.alert_type)}')">Remove</button>
+            </div>
+        </div>
+    `).join('');
+}
+
+function editAlertPolicy(alertType) {
+    const policy = state.alertPolicies.find(p => p.alert_type === alertType);
+    if (!policy) return;
+
+    document.getElementById('policyAlertType').value = policy.alert_type;
+    document.getElementById('policyGraceMinutes').value = policy.grace_minutes;
+    document.getElementById('policyFlapThreshold').value = policy.flap_threshold;
+    document.getElementById('policyFlapWindow').value = policy.flap_window_hours;
+    document.getElementById('policyFlapGrace').value = policy.flap_grace_minutes;
+    document.getElementById('policyVerifyBackups').value = policy.verify_backups;
+    document.getElementById('policyVerifyHours').value = policy.verify_hours;
+    document.getElementById('policyVerifyStatus').value = policy.verify_status;
+}
+
+async function saveAlertPolicy() {
+    const policy = {
+        alert_type: document.getElementById('policyAlertType').value.trim(),
+        grace_minutes: parseInt(document.getElementById('policyGraceMinutes').value) || 0,
+        flap_threshold: parseInt(document.getElementById('policyFlapThreshold').value) || 0,
+        flap_window_hours: parseInt(document.getElementById('policyFlapWindow').value) || 24,
+        flap_grace_minutes: parseInt(document.getElementById('policyFlapGrace').value) || 0,
+        verify_backups: parseInt(document.getElementById('policyVerifyBackups').value) || 0,
+        verify_hours: parseInt(document.getElementById('policyVerifyHours').value) || 0,
+        verify_status: document.getElementById('policyVerifyStatus').value.trim()
+    };
+
+    if (!policy.alert_type) {
+        showConfigStatus('Enter an alert type first', 'error');
+        return;
+    }
+
+    try {
+        const response = await fetch('/api/ticketing/alert-policies/save', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(policy)
+        });
+
+        if (response.ok) {
+            showConfigStatus(`Policy for ${policy.alert_type} saved`, 'success');
+            await loadAlertPolicies();
+        } else {
+            showConfigStatus('Failed to save policy: ' + await response.text(), 'error');
+        }
+    } catch (error) {
+        showConfigStatus('Error: ' + error.message, 'error');
+    }
+}
+
+async function deleteAlertPolicy(alertType) {
+    try {
+        const response = await fetch('/api/ticketing/alert-policies/delete', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ alert_type: alertType })
+        });
+
+        if (response.ok) {
+            showConfigStatus(`${alertType} alerts will be ticketed immediately`, 'success');
+            await loadAlertPolicies();
+        } else {
+            showConfigStatus('Failed to remove policy', 'error');
+        }
+    } catch (error) {
+        showConfigStatus('Error: ' + error.message, 'error');
+    }
+}
+
+async function loadEscalationSteps() {
+    document.getElementById('escalationPriority').innerHTML = '<option value="">Leave as is</option>' +
+        state.priorities.map(p => `<option value="${escapeHtml(p.name)}">${escapeHtml(p.name)}</option>`).join('');
+    document.getElementById('escalationOwner').innerHTML = '<option value="">Leave as is</option>' +
+        state.members.map(m => `<option value="${m.id}" data-name="${escapeHtml(m.firstName + ' ' + m.lastName)}">${escapeHtml(m.firstName + ' ' + m.lastName)}</option>`).join('');
+
+    try {
+        const response = await fetch('/api/escalations');
+        state.escalationSteps = await response.json();
+        renderEscalationSteps();
+    } catch (error) {
+        console.error('Error loading escalation steps:', error);
+    }
+}
+
+function renderEscalationSteps() {
+    const container = document.getElementById('escalationList');
+
+    if (state.escalationSteps.length === 0) {
+        container.innerHTML = '<div class="empty-state"><p>No escalation steps - tickets keep their priority and owner until closed</p></div>';
+        return;
+    }
+
+    container.innerHTML = state.escalationSteps.map(s => {
+        const actions = [];
+        if (s.priority_name) actions.push(`priority ${s.priority_name}`);
+        if (s.owner_id) actions.push(`reassign to ${s.owner_name || s.owner_id}`);
+        if (s.note) actions.push('note');
+
+        return `
+        <div class="mapping-item">
+            <div class="mapping-info">
+                <div class="mapping-title">
+                    ${escapeHtml(s.name)}
+                    ${s.enabled ? '' : '<span class="badge badge-info">Disabled</span>'}
+                </div>
+                <div class="mapping-subtitle">
+                    After ${s.after_hours}h · ${escapeHtml(s.alert_type || 'all alert types')} · ${escapeHtml(actions.join(', '))}
+                </div>
+                <div class="timestamp">Fired on ${s.tickets_escalated} ticket${s.tickets_escalated === 1 ? '' : 's'}</div>
+            </div>
+            <div class="mapping-actions">
+                <button class="btn btn-secondary" onclick="editEscalationStep(${s.id})">Edit</button>
+                <button class="btn btn-danger" onclick="deleteEscalationStep(${s.id})">Remove</button>
+            </div>
+        </div>`;
+    }).join('');
+}
+
+function resetEscalationForm() {
+    document.getElementById('escalationId').value = '';
+    document.getElementById('escalationName').value = '';
+    document.getElementById('escalationAfterHours').value = 8;
+    document.getElementById('escalationAlertType').value = '';
+    document.getElementById('escalationPriority').value = '';
+    document.getElementById('escalationOwner').value = '';
+    document.getElementById('escalationNote').value = '';
+    document.getElementById('escalationEnabled').checked = true;
+}
+
+function editEscalationStep(id) {
+    const step = state.escalationSteps.find(s => s.id === id);
+    if (!step) return;
+
+    document.getElementById('escalationId').value = step.id;
+    document.getElementById('escalationName').value = step.name;
+    document.getElementById('escalationAfterHours').value = step.after_hours;
+    document.getElementById('escalationAlertType').value = step.alert_type;
+    document.getElementById('escalationPriority').value = step.priority_name;
+    document.getElementById('escalationOwner').value = step.owner_id || '';
+    document.getElementById('escalationNote').value = step.note;
+    document.getElementById('escalationEnabled').checked = step.enabled;
+}
+
+async function saveEscalationStep() {
+    const ownerSelect = document.getElementById('escalationOwner');
+    const ownerId = parseInt(ownerSelect.value);
+
+    const step = {
+        id: parseInt(document.getElementById('escalationId').value) || 0,
+        name: document.getElementById('escalationName').value.trim(),
+        enabled: document.getElementById('escalationEnabled').checked,
+        alert_type: document.getElementById('escalationAlertType').value.trim(),
+        after_hours: parseInt(document.getElementById('escalationAfterHours').value) || 0,
+        priority_name: document.getElementById('escalationPriority').value,
+        owner_id: ownerId || undefined,
+        owner_name: ownerId ? ownerSelect.selectedOptions[0].dataset.name : '',
+        note: document.getElementById('escalationNote').value
+    };
+
+    if (!step.name) {
+        showConfigStatus('Enter a name for the escalation step first', 'error');
+        return;
+    }
+
+    try {
+        const response = await fetch('/api/escalations/save', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(step)
+        });
+
+        if (response.ok) {
+            showConfigStatus(`Escalation step ${step.name} saved`, 'success');
+            resetEscalationForm();
+            await loadEscalationSteps();
+        } else {
+            showConfigStatus('Failed to save escalation step: ' + await response.text(), 'error');
+        }
+    } catch (error) {
+        showConfigStatus('Error: ' + error.message, 'error');
+    }
+}
+
+async function deleteEscalationStep(id) {
+    try {
+        const response = await fetch('/api/escalations/delete', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ id })
+        });
+
+        if (response.ok) {
+            showConfigStatus('Escalation step removed', 'success');
+            await loadEscalationSteps();
+        } else {
+            showConfigStatus('Failed to remove escalation step', 'error');
+        }
+    } catch (error) {
+        showConfigStatus('Error: ' + error.message, 'error');
+    }
+}
+
 function previewTemplate() {
     const summary = document.getElementById('ticketSummary').value;
     const template = document.getElementById('ticketTemplate').value;
@@ -518,6 +1033,8 @@ async function closeAlert(alertId) {
 function initTickets() {
     document.getElementById('refreshTicketsBtn').addEventListener('click', loadTicketMappings);
     document.getElementById('openTicketsOnly').addEventListener('change', renderTicketMappings);
+    document.getElementById('refreshWorkQueueBtn').addEventListener('click', loadWorkQueue);
+    document.getElementById('failedWorkOnly').addEventListener('change', renderWorkQueue);
 }
 
 async function loadTicketMappings() {
@@ -561,6 +1078,7 @@ function renderTicketMappings() {
 
         // Show sync warning if ticket is closed in CW but not in our DB
         const syncWarning = ticket.needsSync ? '<span class="badge badge-warning">⚠ Needs Sync</span>' : '';
+        const verifyBadge = ticket.verifyingSince ? '<span class="badge badge-info">⏳ Pending Verification</span>' : '';
 
         return `
             <div class="ticket-item">
@@ -569,6 +1087,7 @@ function renderTicketMappings() {
                         Alert: ${ticket.alertId} → Ticket #${ticket.ticketId}
                         ${statusBadge}
                         ${syncWarning}
+                        ${verifyBadge}
                     </div>
                     <div class="alert-subtitle">
                         ConnectWise Status: ${ticket.ticketStatus || 'Unknown'}
@@ -578,12 +1097,661 @@ function renderTicketMappings() {
                         Created: ${new Date(ticket.createdAt).toLocaleString()}
                         ${ticket.closedAt ? ` • Closed in DB: ${new Date(ticket.closedAt).toLocaleString()}` : ''}
                     </div>
+                    ${ticket.resolution ? `<div class="alert-subtitle">${ticket.verifyingSince ? 'Pending verification' : 'Resolved'}: ${escapeHtml(ticket.resolution)}</div>` : ''}
                 </div>
             </div>
         `;
     }).join('');
 }
 
+// Retry queue
+const workActionLabels = {
+    create_ticket: 'Create ticket',
+    close_ticket: 'Close ticket',
+    close_alert: 'Close Slide alert'
+};
+
+// formatAge describes how long ago a timestamp was, e.g. "3h 12m"
+function formatAge(timestamp) {
+    const minutes = Math.max(0, Math.floor((Date.now() - new Date(timestamp)) / 60000));
+    if (minutes < 60) return `${minutes}m`;
+    const hours = Math.floor(minutes / 60);
+    if (hours < 24) return `${hours}h ${minutes % 60}m`;
+    return `${Math.floor(hours / 24)}d ${hours % 24}h`;
+}
+
+async function loadWorkQueue() {
+    const container = document.getElementById('workQueueList');
+    container.innerHTML = '<div class="loading">Loading retry queue...</div>';
+
+    try {
+        const response = await fetch('/api/queue');
+        state.workQueue = await response.json();
+        renderWorkQueue();
+    } catch (error) {
+        container.innerHTML = '<div class="empty-state"><div class="empty-state-icon">⚠️</div><p>Error loading retry queue</p></div>';
+        console.error('Error loading retry queue:', error);
+    }
+}
+
+function renderWorkQueue() {
+    const container = document.getElementById('workQueueList');
+    const failedOnly = document.getElementById('failedWorkOnly').checked;
+
+    const filtered = state.workQueue.filter(item => !failedOnly || item.status === 'failed');
+
+    if (filtered.length === 0) {
+        container.innerHTML = '<div class="empty-state"><div class="empty-state-icon">✅</div><p>Nothing waiting to be retried</p></div>';
+        return;
+    }
+
+    container.innerHTML = filtered.map(item => {
+        const statusBadge = {
+            failed: '<span class="badge badge-danger">✗ Failed</span>',
+            skipped: '<span class="badge badge-info">⏭ Skipped</span>'
+        }[item.status] || '<span class="badge badge-warning">⏳ Retrying</span>';
+
+        // Until a failed ticket creation is retried or discarded its alert gets no ticket
+        let blocking = '';
+        if (item.status === 'failed') {
+            blocking = item.action === 'create_ticket'
+                ? `<div class="alert-subtitle">Alert has had no ticket for ${formatAge(item.created_at)}</div>`
+                : `<div class="alert-subtitle">Waiting for you for ${formatAge(item.updated_at)}</div>`;
+        } else if (item.status === 'skipped') {
+            blocking = '<div class="alert-subtitle">Discarded - the alert won\'t be ticketed unless you retry this</div>';
+        }
+
+        return `
+            <div class="ticket-item">
+                <div class="ticket-info">
+                    <div class="alert-title">
+                        ${workActionLabels[item.action] || escapeHtml(item.action)}: alert ${escapeHtml(item.alert_id)}
+                        ${item.ticket_id ? ` → Ticket #${item.ticket_id}` : ''}
+                        ${statusBadge}
+                    </div>
+                    <div class="alert-subtitle">${escapeHtml(item.last_error || '')}</div>
+                    ${blocking}
+                    <div class="timestamp">
+                        Attempts: ${item.attempts}
+                        • Queued: ${new Date(item.created_at).toLocaleString()}
+                        ${item.status !== 'pending' ? '' : ` • Next attempt: ${new Date(item.next_attempt_at).toLocaleString()}`}
+                    </div>
+                </div>
+                <div class="alert-actions">
+                    <button class="btn btn-primary" onclick="retryWorkItem(${item.id})">🔁 Retry</button>
+                    ${item.status === 'skipped' ? '' : `<button class="btn btn-danger" onclick="discardWorkItem(${item.id}, '${item.action}')">🗑️ Discard</button>`}
+                </div>
+            </div>
+        `;
+    }).join('');
+}
+
+async function retryWorkItem(id) {
+    try {
+        const response = await fetch('/api/queue/retry', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ id })
+        });
+
+        if (response.ok) {
+            showNotification('Queued for retry - it will run within a minute', 'success');
+            loadWorkQueue();
+        } else {
+            showNotification('Failed to retry: ' + await response.text(), 'error');
+        }
+    } catch (error) {
+        showNotification('Error: ' + error.message, 'error');
+    }
+}
+
+async function discardWorkItem(id, action) {
+    const message = action === 'create_ticket'
+        ? 'Discard this item? The alert will not be ticketed unless you retry it here, and it is cleared once the alert resolves.'
+        : 'Discard this item? It will not be retried again.';
+    if (!confirm(message)) return;
+
+    try {
+        const response = await fetch('/api/queue/discard', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ id })
+        });
+
+        if (response.ok) {
+            loadWorkQueue();
+        } else {
+            showNotification('Failed to discard: ' + await response.text(), 'error');
+        }
+    } catch (error) {
+        showNotification('Error: ' + error.message, 'error');
+    }
+}
+
+// Maintenance windows
+function initMaintenance() {
+    document.getElementById('maintenanceForm').addEventListener('submit', async (e) => {
+        e.preventDefault();
+        await saveMaintenanceWindow();
+    });
+    document.getElementById('resetMaintenanceBtn').addEventListener('click', resetMaintenanceForm);
+    document.getElementById('refreshMaintenanceBtn').addEventListener('click', loadMaintenanceWindows);
+    resetMaintenanceForm();
+}
+
+async function loadMaintenanceWindows() {
+    const container = document.getElementById('maintenanceList');
+    container.innerHTML = '<div class="loading">Loading maintenance windows...</div>';
+
+    try {
+        const response = await fetch('/api/maintenance');
+        state.maintenanceWindows = await response.json();
+        renderMaintenanceWindows();
+    } catch (error) {
+        container.innerHTML = '<div class="empty-state"><div class="empty-state-icon">⚠️</div><p>Error loading maintenance windows</p></div>';
+        console.error('Error loading maintenance windows:', error);
+    }
+
+    // Suggest client IDs for client-scoped windows
+    try {
+        if (state.slideClients.length === 0) {
+            const response = await fetch('/api/slide/clients');
+            state.slideClients = await response.json();
+        }
+        document.getElementById('maintenanceClientOptions').innerHTML = state.slideClients
+            .map(c => `<option value="${escapeHtml(c.id)}">${escapeHtml(c.name)}</option>`).join('');
+    } catch (error) {
+        console.error('Error loading Slide clients:', error);
+    }
+}
+
+function renderMaintenanceWindows() {
+    const container = document.getElementById('maintenanceList');
+
+    if (state.maintenanceWindows.length === 0) {
+        container.innerHTML = '<div class="empty-state"><div class="empty-state-icon">🛠️</div><p>No maintenance windows</p></div>';
+        return;
+    }
+
+    const repeats = { once: 'One-off', daily: 'Daily', weekly: 'Weekly' };
+
+    container.innerHTML = state.maintenanceWindows.map(mw => `
+        <div class="mapping-item">
+            <div class="mapping-info">
+                <div class="mapping-title">
+                    ${escapeHtml(mw.name)}
+                    ${mw.active
+                        ? '<span class="badge badge-warning">Active</span>'
+                        : '<span class="badge badge-info">Scheduled</span>'}
+                </div>
+                <div class="mapping-subtitle">
+                    ${escapeHtml(mw.scope)} ${escapeHtml(mw.scope_id)} ·
+                    ${repeats[mw.recurrence] || escapeHtml(mw.recurrence)} from ${escapeHtml(mw.starts_at.replace('T', ' '))} ${escapeHtml(mw.timezone)}
+                    for ${mw.duration_minutes} min
+                </div>
+                <div class="timestamp">${mw.alerts_recorded} alert${mw.alerts_recorded === 1 ? '' : 's'} held</div>
+                <div id="maintenanceAlerts-${mw.id}"></div>
+            </div>
+            <div class="mapping-actions">
+                ${mw.alerts_recorded > 0 ? `<button class="btn btn-secondary" onclick="showMaintenanceAlerts(${mw.id})">Alerts</button>` : ''}
+                <button class="btn btn-secondary" onclick="editMaintenanceWindow(${mw.id})">Edit</button>
+                <button class="btn btn-danger" onclick="deleteMaintenanceWindow(${mw.id})">Delete</button>
+            </div>
+        </div>
+    `).join('');
+}
+
+async function showMaintenanceAlerts(id) {
+    const container = document.getElementById(`maintenanceAlerts-${id}`);
+
+    try {
+        const response = await fetch(`/api/maintenance/alerts?windowId=${id}`);
+        const alerts = await response.json();
+
+        container.innerHTML = alerts.map(a => `
+            <div class="timestamp">${escapeHtml(a.alert_id)} · ${escapeHtml(a.alert_type)} · agent ${escapeHtml(a.agent_id)} · ${new Date(a.recorded_at).toLocaleString()}</div>
+        `).join('');
+    } catch (error) {
+        console.error('Error loading maintenance alerts:', error);
+    }
+}
+
+function resetMaintenanceForm() {
+    document.getElementById('maintenanceForm').reset();
+    document.getElementById('maintenanceId').value = '';
+    document.getElementById('maintenanceFormTitle').textContent = 'New Window';
+    document.getElementById('maintenanceTimezone').value = Intl.DateTimeFormat().resolvedOptions().timeZone || 'UTC';
+}
+
+function editMaintenanceWindow(id) {
+    const mw = state.maintenanceWindows.find(w => w.id === id);
+    if (!mw) return;
+
+    document.getElementById('maintenanceId').value = mw.id;
+    document.getElementById('maintenanceFormTitle').textContent = `Edit ${mw.name}`;
+    document.getElementById('maintenanceName').value = mw.name;
+    document.getElementById('maintenanceScope').value = mw.scope;
+    document.getElementById('maintenanceScopeId').value = mw.scope_id;
+    document.getElementById('maintenanceStart').value = mw.starts_at;
+    document.getElementById('maintenanceTimezone').value = mw.timezone;
+    document.getElementById('maintenanceDuration').value = mw.duration_minutes;
+    document.getElementById('maintenanceRecurrence').value = mw.recurrence;
+}
+
+async function saveMaintenanceWindow() {
+    const maintenanceWindow = {
+        id: parseInt(document.getElementById('maintenanceId').value) || 0,
+        name: document.getElementById('maintenanceName').value,
+        scope: document.getElementById('maintenanceScope').value,
+        scope_id: document.getElementById('maintenanceScopeId').value,
+        starts_at: document.getElementById('maintenanceStart').value,
+        timezone: document.getElementById('maintenanceTimezone').value.trim(),
+        duration_minutes: parseInt(document.getElementById('maintenanceDuration').value) || 0,
+        recurrence: document.getElementById('maintenanceRecurrence').value
+    };
+
+    try {
+        const response = await fetch('/api/maintenance/save', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(maintenanceWindow)
+        });
+
+        if (response.ok) {
+            showMaintenanceStatus('Maintenance window saved', 'success');
+            resetMaintenanceForm();
+            await loadMaintenanceWindows();
+        } else {
+            showMaintenanceStatus('Failed to save: ' + await response.text(), 'error');
+        }
+    } catch (error) {
+        showMaintenanceStatus('Error: ' + error.message, 'error');
+    }
+}
+
+async function deleteMaintenanceWindow(id) {
+    if (!confirm('Delete this maintenance window? Alerts it is holding back will be ticketed on the next check.')) return;
+
+    try {
+        const response = await fetch('/api/maintenance/delete', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ id })
+        });
+
+        if (response.ok) {
+            await loadMaintenanceWindows();
+        } else {
+            showMaintenanceStatus('Failed to delete: ' + await response.text(), 'error');
+        }
+    } catch (error) {
+        showMaintenanceStatus('Error: ' + error.message, 'error');
+    }
+}
+
+function showMaintenanceStatus(message, type) {
+    const statusEl = document.getElementById('maintenanceStatus');
+    statusEl.textContent = message;
+    statusEl.className = `status-message ${type}`;
+
+    setTimeout(() => {
+        statusEl.className = 'status-message';
+    }, 5000);
+}
+
+// Routing rules
+function initRouting() {
+    document.getElementById('routingForm').addEventListener('submit', async (e) => {
+        e.preventDefault();
+        await saveRoutingRule();
+    });
+    document.getElementById('routingTestForm').addEventListener('submit', async (e) => {
+        e.preventDefault();
+        await testRoutingRules();
+    });
+    document.getElementById('routingBoard').addEventListener('change', (e) => loadRoutingStatusesAndTypes(e.target.value));
+    document.getElementById('routingAction').addEventListener('change', toggleRoutingTicketFields);
+    document.getElementById('resetRoutingBtn').addEventListener('click', resetRoutingForm);
+    document.getElementById('refreshRoutingBtn').addEventListener('click', loadRoutingRules);
+}
+
+async function loadRoutingRules() {
+    const container = document.getElementById('routingList');
+    container.innerHTML = '<div class="loading">Loading routing rules...</div>';
+
+    try {
+        const response = await fetch('/api/routing/rules');
+        state.routingRules = await response.json();
+        renderRoutingRules();
+    } catch (error) {
+        container.innerHTML = '<div class="empty-state"><div class="empty-state-icon">⚠️</div><p>Error loading routing rules</p></div>';
+        console.error('Error loading routing rules:', error);
+    }
+
+    try {
+        await loadRoutingOptions();
+    } catch (error) {
+        console.error('Error loading routing options:', error);
+    }
+}
+
+// Fill the condition and ticket setting dropdowns, fetching anything another tab hasn't yet
+async function loadRoutingOptions() {
+    const lists = [
+        ['slideClients', '/api/slide/clients'],
+        ['cwClients', '/api/connectwise/clients'],
+        ['boards', '/api/connectwise/boards'],
+        ['priorities', '/api/connectwise/priorities'],
+        ['members', '/api/connectwise/members']
+    ].filter(([key]) => state[key].length === 0);
+
+    const responses = await Promise.all(lists.map(([, url]) => fetch(url)));
+    for (let i = 0; i < lists.length; i++) {
+        state[lists[i][0]] = await responses[i].json();
+    }
+
+    const clientOptions = '<option value="">Any client</option>' +
+        state.slideClients.map(c => `<option value="${escapeHtml(c.id)}">${escapeHtml(c.name)}</option>`).join('');
+    document.getElementById('routingMatchClient').innerHTML = clientOptions;
+    document.getElementById('routingTestClient').innerHTML = clientOptions;
+
+    document.getElementById('routingMatchCompany').innerHTML = '<option value="">Any company</option>' +
+        state.cwClients.map(c => `<option value="${c.id}">${escapeHtml(c.name)}</option>`).join('');
+
+    document.getElementById('routingBoard').innerHTML = '<option value="">Ticketing Config board</option>' +
+        state.boards.map(b => `<option value="${b.id}" data-name="${escapeHtml(b.name)}">${escapeHtml(b.name)}</option>`).join('');
+
+    document.getElementById('routingPriority').innerHTML = '<option value="">Ticketing Config priority</option>' +
+        state.priorities.map(p => `<option value="${escapeHtml(p.name)}">${escapeHtml(p.name)}</option>`).join('');
+
+    document.getElementById('routingOwner').innerHTML = '<option value="">Ticketing Config assignment</option>' +
+        state.members.map(m => `<option value="${m.id}" data-name="${escapeHtml(m.firstName + ' ' + m.lastName)}">${escapeHtml(m.firstName + ' ' + m.lastName)}</option>`).join('');
+
+    if (!document.getElementById('routingRuleId').value) {
+        resetRoutingForm();
+    }
+}
+
+async function loadRoutingStatusesAndTypes(boardId) {
+    const statusSelect = document.getElementById('routingStatus');
+    const typeSelect = document.getElementById('routingType');
+
+    if (!boardId) {
+        statusSelect.innerHTML = '<option value="">Ticketing Config status</option>';
+        typeSelect.innerHTML = '<option value="">Ticketing Config type</option>';
+        return;
+    }
+
+    try {
+        const [statusesRes, typesRes] = await Promise.all([
+            fetch(`/api/connectwise/statuses?boardId=${boardId}`),
+            fetch(`/api/connectwise/types?boardId=${boardId}`)
+        ]);
+        const statuses = await statusesRes.json();
+        const types = await typesRes.json();
+
+        statusSelect.innerHTML = '<option value="">Select a status...</option>' +
+            statuses.map(s => `<option value="${escapeHtml(s.name)}">${escapeHtml(s.name)}</option>`).join('');
+        typeSelect.innerHTML = '<option value="">No type</option>' +
+            types.map(t => `<option value="${escapeHtml(t.name)}">${escapeHtml(t.name)}</option>`).join('');
+    } catch (error) {
+        console.error('Error loading routing statuses and types:', error);
+    }
+}
+
+function toggleRoutingTicketFields() {
+    const ignore = document.getElementById('routingAction').value === 'ignore';
+    document.getElementById('routingTicketFields').style.display = ignore ? 'none' : 'block';
+}
+
+function describeRoutingRule(rule) {
+    const when = [];
+    if (rule.match_alert_type) when.push(`type ${rule.match_alert_type}`);
+    if (rule.match_client_id) {
+        const client = state.slideClients.find(c => c.id === rule.match_client_id);
+        when.push(`client ${client ? client.name : rule.match_client_id}`);
+    }
+    if (rule.match_company_id) {
+        const company = state.cwClients.find(c => c.id === rule.match_company_id);
+        when.push(`company ${company ? company.name : rule.match_company_id}`);
+    }
+    if (rule.match_device) when.push(`device ${rule.match_device}`);
+    if (rule.match_agent_name) when.push(`agent ${rule.match_agent_name}`);
+    if (rule.match_message) when.push(`message /${rule.match_message}/`);
+
+    let then;
+    if (rule.action === 'ignore') {
+        then = 'no ticket';
+    } else {
+        const settings = [];
+        if (rule.board_name) settings.push(`${rule.board_name} / ${rule.status_name}`);
+        if (rule.type_name) settings.push([rule.type_name, rule.subtype_name, rule.item_name].filter(Boolean).join(' / '));
+        if (rule.priority_name) settings.push(rule.priority_name);
+        if (rule.owner_name) settings.push(`owner ${rule.owner_name}`);
+        if (rule.summary_template || rule.description_template) settings.push('custom templates');
+        then = settings.length > 0 ? settings.join(' · ') : 'Ticketing Config defaults';
+    }
+
+    return `${when.length > 0 ? when.join(', ') : 'any alert'} → ${then}`;
+}
+
+function renderRoutingRules() {
+    const container = document.getElementById('routingList');
+
+    if (state.routingRules.length === 0) {
+        container.innerHTML = '<div class="empty-state"><div class="empty-state-icon">🧭</div><p>No routing rules, every alert uses the Ticketing Config</p></div>';
+        return;
+    }
+
+    const last = state.routingRules.length - 1;
+    container.innerHTML = state.routingRules.map((rule, i) => `
+        <div class="mapping-item">
+            <div class="mapping-info">
+                <div class="mapping-title">
+                    ${i + 1}. ${escapeHtml(rule.name)}
+                    ${!rule.enabled
+                        ? '<span class="badge badge-info">Disabled</span>'
+                        : rule.action === 'ignore'
+                            ? '<span class="badge badge-warning">Ignore</span>'
+                            : ''}
+                </div>
+                <div class="mapping-subtitle">${escapeHtml(describeRoutingRule(rule))}</div>
+            </div>
+            <div class="mapping-actions">
+                <button class="btn btn-secondary" onclick="moveRoutingRule(${i}, -1)" ${i === 0 ? 'disabled' : ''}>↑</button>
+                <button class="btn btn-secondary" onclick="moveRoutingRule(${i}, 1)" ${i === last ? 'disabled' : ''}>↓</button>
+                <button class="btn btn-secondary" onclick="editRoutingRule(${rule.id})">Edit</button>
+                <button class="btn btn-danger" onclick="deleteRoutingRule(${rule.id})">Delete</button>
+            </div>
+        </div>
+    `).join('');
+}
+
+function resetRoutingForm() {
+    document.getElementById('routingForm').reset();
+    document.getElementById('routingRuleId').value = '';
+    document.getElementById('routingFormTitle').textContent = 'New Rule';
+    loadRoutingStatusesAndTypes('');
+    toggleRoutingTicketFields();
+}
+
+async function editRoutingRule(id) {
+    const rule = state.routingRules.find(r => r.id === id);
+    if (!rule) return;
+
+    document.getElementById('routingRuleId').value = rule.id;
+    document.getElementById('routingFormTitle').textContent = `Edit ${rule.name}`;
+    document.getElementById('routingName').value = rule.name;
+    document.getElementById('routingEnabled').checked = rule.enabled;
+    document.getElementById('routingMatchAlertType').value = rule.match_alert_type;
+    document.getElementById('routingMatchClient').value = rule.match_client_id;
+    document.getElementById('routingMatchCompany').value = rule.match_company_id || '';
+    document.getElementById('routingMatchDevice').value = rule.match_device;
+    document.getElementById('routingMatchAgent').value = rule.match_agent_name;
+    document.getElementById('routingMatchMessage').value = rule.match_message;
+    document.getElementById('routingAction').value = rule.action;
+    document.getElementById('routingBoard').value = rule.board_id || '';
+    document.getElementById('routingSubType').value = rule.subtype_name;
+    document.getElementById('routingItem').value = rule.item_name;
+    document.getElementById('routingPriority').value = rule.priority_name;
+    document.getElementById('routingOwner').value = rule.owner_id || '';
+    document.getElementById('routingSummaryTemplate').value = rule.summary_template;
+    document.getElementById('routingDescriptionTemplate').value = rule.description_template;
+    toggleRoutingTicketFields();
+
+    await loadRoutingStatusesAndTypes(rule.board_id || '');
+    document.getElementById('routingStatus').value = rule.status_name;
+    document.getElementById('routingType').value = rule.type_name;
+}
+
+async function saveRoutingRule() {
+    const boardSelect = document.getElementById('routingBoard');
+    const ownerSelect = document.getElementById('routingOwner');
+    const ownerId = parseInt(ownerSelect.value);
+
+    const rule = {
+        id: parseInt(document.getElementById('routingRuleId').value) || 0,
+        name: document.getElementById('routingName').value,
+        enabled: document.getElementById('routingEnabled').checked,
+        action: document.getElementById('routingAction').value,
+        match_alert_type: document.getElementById('routingMatchAlertType').value.trim(),
+        match_client_id: document.getElementById('routingMatchClient').value,
+        match_company_id: parseInt(document.getElementById('routingMatchCompany').value) || 0,
+        match_device: document.getElementById('routingMatchDevice').value.trim(),
+        match_agent_name: document.getElementById('routingMatchAgent').value.trim(),
+        match_message: document.getElementById('routingMatchMessage').value,
+        board_id: parseInt(boardSelect.value) || 0,
+        board_name: boardSelect.value ? boardSelect.selectedOptions[0].dataset.name : '',
+        status_name: document.getElementById('routingStatus').value,
+        type_name: document.getElementById('routingType').value,
+        subtype_name: document.getElementById('routingSubType').value.trim(),
+        item_name: document.getElementById('routingItem').value.trim(),
+        priority_name: document.getElementById('routingPriority').value,
+        owner_id: ownerId || undefined,
+        owner_name: ownerId ? ownerSelect.selectedOptions[0].dataset.name : '',
+        summary_template: document.getElementById('routingSummaryTemplate').value,
+        description_template: document.getElementById('routingDescriptionTemplate').value
+    };
+
+    try {
+        const response = await fetch('/api/routing/rules/save', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(rule)
+        });
+
+        if (response.ok) {
+            showRoutingStatus('Routing rule saved', 'success');
+            resetRoutingForm();
+            await loadRoutingRules();
+        } else {
+            showRoutingStatus('Failed to save: ' + await response.text(), 'error');
+        }
+    } catch (error) {
+        showRoutingStatus('Error: ' + error.message, 'error');
+    }
+}
+
+async function moveRoutingRule(index, offset) {
+    const ids = state.routingRules.map(r => r.id);
+    const [id] = ids.splice(index, 1);
+    ids.splice(index + offset, 0, id);
+
+    try {
+        const response = await fetch('/api/routing/rules/reorder', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ ids })
+        });
+
+        if (response.ok) {
+            await loadRoutingRules();
+        } else {
+            showRoutingStatus('Failed to reorder: ' + await response.text(), 'error');
+        }
+    } catch (error) {
+        showRoutingStatus('Error: ' + error.message, 'error');
+    }
+}
+
+async function deleteRoutingRule(id) {
+    if (!confirm('Delete this routing rule?')) return;
+
+    try {
+        const response = await fetch('/api/routing/rules/delete', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify({ id })
+        });
+
+        if (response.ok) {
+            await loadRoutingRules();
+        } else {
+            showRoutingStatus('Failed to delete: ' + await response.text(), 'error');
+        }
+    } catch (error) {
+        showRoutingStatus('Error: ' + error.message, 'error');
+    }
+}
+
+async function testRoutingRules() {
+    const container = document.getElementById('routingTestResult');
+    const facts = {
+        alert_type: document.getElementById('routingTestAlertType').value.trim(),
+        client_id: document.getElementById('routingTestClient').value,
+        device_id: document.getElementById('routingTestDevice').value.trim(),
+        device_name: document.getElementById('routingTestDevice').value.trim(),
+        agent_name: document.getElementById('routingTestAgent').value.trim(),
+        message: document.getElementById('routingTestMessage').value
+    };
+
+    try {
+        const response = await fetch('/api/routing/test', {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(facts)
+        });
+        if (!response.ok) {
+            container.innerHTML = `<div class="status-message error">${escapeHtml(await response.text())}</div>`;
+            return;
+        }
+        const result = await response.json();
+
+        const matched = result.rule
+            ? `Matches rule <strong>${escapeHtml(result.rule.name)}</strong>`
+            : 'No rule matches, so the Ticketing Config applies';
+
+        let outcome;
+        if (result.ignored) {
+            outcome = 'The alert is ignored and no ticket is created.';
+        } else if (!result.config) {
+            outcome = 'Ticketing is not configured yet.';
+        } else {
+            const c = result.config;
+            outcome = [
+                `Board: ${escapeHtml(c.board_name)}`,
+                `Status: ${escapeHtml(c.status_name)}`,
+                `Type: ${escapeHtml([c.type_name, c.subtype_name, c.item_name].filter(Boolean).join(' / ') || 'none')}`,
+                `Priority: ${escapeHtml(c.priority_name)}`
+            ].join('<br>');
+        }
+
+        container.innerHTML = `<div class="info-box"><p>${matched}</p><p>${outcome}</p></div>`;
+    } catch (error) {
+        container.innerHTML = `<div class="status-message error">Error: ${escapeHtml(error.message)}</div>`;
+    }
+}
+
+function showRoutingStatus(message, type) {
+    const statusEl = document.getElementById('routingStatusMessage');
+    statusEl.textContent = message;
+    statusEl.className = `status-message ${type}`;
+
+    setTimeout(() => {
+        statusEl.className = 'status-message';
+    }, 5000);
+}
+
 // Modal handlers
 function initModals() {
     const modal = document.getElementById('mappingModal');
@@ -0,0 +1,127 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"slide-cw-integration/internal/slide"
+	"slide-cw-integration/pkg/models"
+)
+
+const (
+	// slideWebhookPath is where Slide delivers alert events
+	slideWebhookPath = "/api/webhooks/slide"
+
+	// maxWebhookBody caps how much of a webhook request we read
+	maxWebhookBody = 1 << 20
+
+	webhookProcessTimeout = 2 * time.Minute
+)
+
+// AlertEventHandler processes alerts pushed by Slide. *alerts.Monitor satisfies it.
+type AlertEventHandler interface {
+	HandleAlertEvent(ctx context.Context, alert *models.SlideAlert) error
+}
+
+// webhookState tracks inbound Slide webhook deliveries
+type webhookState struct {
+	secret  string
+	handler AlertEventHandler
+
+	mu           sync.Mutex
+	received     int
+	rejected     int
+	lastReceived time.Time
+	lastError    string
+}
+
+// EnableSlideWebhooks has the server accept Slide alert events signed with secret
+// and pass them to handler. The monitor keeps polling as a fallback.
+func (s *Server) EnableSlideWebhooks(secret string, handler AlertEventHandler) {
+	s.webhooks = &webhookState{
+		secret:  secret,
+		handler: handler,
+	}
+}
+
+// Slide alert webhook
+func (s *Server) handleSlideWebhook(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	wh := s.webhooks
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	event, err := slide.ParseWebhook(wh.secret, body,
+		r.Header.Get(slide.WebhookSignatureHeader), r.Header.Get(slide.WebhookTimestampHeader))
+	if err != nil {
+		log.Printf("Rejected Slide webhook: %v", err)
+
+		wh.mu.Lock()
+		wh.rejected++
+		wh.lastError = err.Error()
+		wh.mu.Unlock()
+
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	wh.mu.Lock()
+	wh.received++
+	wh.lastReceived = time.Now()
+	wh.mu.Unlock()
+
+	// Answer Slide right away; ticketing happens in the background
+	go func(alert models.SlideAlert) {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookProcessTimeout)
+		defer cancel()
+
+		if err := wh.handler.HandleAlertEvent(ctx, &alert); err != nil {
+			log.Printf("Error handling Slide webhook for alert %s: %v", alert.ID, err)
+		}
+	}(event.Alert)
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"status": "accepted"})
+}
+
+// Slide webhook delivery status
+func (s *Server) handleSlideWebhookStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if s.webhooks == nil {
+		json.NewEncoder(w).Encode(map[string]interface{}{"enabled": false})
+		return
+	}
+
+	wh := s.webhooks
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+
+	status := map[string]interface{}{
+		"enabled":  true,
+		"path":     slideWebhookPath,
+		"received": wh.received,
+		"rejected": wh.rejected,
+	}
+	if !wh.lastReceived.IsZero() {
+		status["lastReceived"] = wh.lastReceived
+	}
+	if wh.lastError != "" {
+		status["lastError"] = wh.lastError
+	}
+
+	json.NewEncoder(w).Encode(status)
+}
@@ -2,16 +2,38 @@ package models
 
 import (
 	"encoding/json"
+	"fmt"
+	"regexp"
 	"strings"
 	"time"
 )
 
 // SlideDevice represents a device from the Slide API
 type SlideDevice struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	ClientID string `json:"client_id"`
-	Type     string `json:"type"`
+	ID                string `json:"id"`
+	Name              string `json:"name"`
+	ClientID          string `json:"client_id"`
+	Type              string `json:"type"`
+	StorageUsedBytes  int64  `json:"storage_used_bytes,omitempty"`
+	StorageTotalBytes int64  `json:"storage_total_bytes,omitempty"`
+}
+
+// StorageUsedPercent is how full the device's storage is, or -1 when the device did not report it
+func (d *SlideDevice) StorageUsedPercent() float64 {
+	if d.StorageTotalBytes <= 0 {
+		return -1
+	}
+	return float64(d.StorageUsedBytes) / float64(d.StorageTotalBytes) * 100
+}
+
+// SlideAgent represents a protected agent from the Slide API
+type SlideAgent struct {
+	ID          string     `json:"agent_id"`
+	DeviceID    string     `json:"device_id"`
+	ClientID    string     `json:"client_id"`
+	DisplayName string     `json:"display_name"`
+	Hostname    string     `json:"hostname"`
+	LastSeenAt  *time.Time `json:"last_seen_at,omitempty"`
 }
 
 // SlideClient represents a client from the Slide API
@@ -159,6 +181,28 @@ type SlideBackup struct {
 	ErrorMessage string    `json:"error_message,omitempty"`
 }
 
+// SlideReplication represents a replication of a device's snapshots from the Slide API
+type SlideReplication struct {
+	ID          string     `json:"replication_id"`
+	DeviceID    string     `json:"device_id"`
+	AgentID     string     `json:"agent_id,omitempty"`
+	ClientID    string     `json:"client_id"`
+	StartTime   time.Time  `json:"start_time"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Success     bool       `json:"success"`
+}
+
+// AlertResolution is the evidence a resolver found that an alert's problem has cleared
+type AlertResolution struct {
+	// Resolver names the check that found it, e.g. "backup" or "agent_check_in"
+	Resolver   string    `json:"resolver"`
+	Evidence   string    `json:"evidence"`
+	ObservedAt time.Time `json:"observed_at"`
+	// Pending means the problem looks cleared but the alert type's policy wants more
+	// evidence before it is closed
+	Pending bool `json:"pending,omitempty"`
+}
+
 // ConnectWiseClient represents a client in ConnectWise
 type ConnectWiseClient struct {
 	ID   int    `json:"id"`
@@ -178,12 +222,17 @@ type ConnectWiseTicket struct {
 		ID   int    `json:"id"`
 		Name string `json:"name"`
 	} `json:"company"`
+	Board struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"board"`
+	ClosedFlag bool `json:"closedFlag"`
 }
 
 // IsClosed returns true if the ticket is in a closed status
 func (t *ConnectWiseTicket) IsClosed() bool {
-	// Check the closedStatus flag first
-	if t.Status.ClosedStatus {
+	// Check the closed flags first
+	if t.ClosedFlag || t.Status.ClosedStatus {
 		return true
 	}
 
@@ -213,6 +262,17 @@ func (t *ConnectWiseTicket) IsClosed() bool {
 	return false
 }
 
+// ConnectWiseCallback is a ConnectWise callback (webhook) subscription
+type ConnectWiseCallback struct {
+	ID           int    `json:"id,omitempty"`
+	Description  string `json:"description"`
+	URL          string `json:"url"`
+	ObjectID     int    `json:"objectId"`
+	Type         string `json:"type"`
+	Level        string `json:"level"`
+	InactiveFlag bool   `json:"inactiveFlag"`
+}
+
 // ClientMapping represents the mapping between Slide and ConnectWise clients
 type ClientMapping struct {
 	ID                int    `json:"id" db:"id"`
@@ -220,6 +280,8 @@ type ClientMapping struct {
 	SlideClientName   string `json:"slide_client_name" db:"slide_client_name"`
 	ConnectWiseID     int    `json:"connectwise_id" db:"connectwise_id"`
 	ConnectWiseName   string `json:"connectwise_name" db:"connectwise_name"`
+	// SyncPolicy overrides the global sync policy for this client; empty uses the global one
+	SyncPolicy        SyncPolicy `json:"sync_policy,omitempty" db:"sync_policy"`
 	CreatedAt         time.Time `json:"created_at" db:"created_at"`
 }
 
@@ -228,6 +290,20 @@ type AlertTicketMapping struct {
 	ID        int       `json:"id" db:"id"`
 	AlertID   string    `json:"alert_id" db:"alert_id"`
 	TicketID  int       `json:"ticket_id" db:"ticket_id"`
+	AgentID   string    `json:"agent_id" db:"agent_id"`
+	AgentName string    `json:"agent_name,omitempty" db:"agent_name"`
+	ClientID  string    `json:"client_id,omitempty" db:"client_id"`
+	AlertType string    `json:"alert_type" db:"alert_type"`
+	// CorrelationKey groups alerts sharing one ticket; empty when correlation was off
+	CorrelationKey string `json:"correlation_key,omitempty" db:"correlation_key"`
+	// Resolution is the evidence the alert was auto-resolved on, if it was
+	Resolution string `json:"resolution,omitempty" db:"resolution"`
+	// VerifyingSince is when the alert's problem first looked cleared while its
+	// backups are still being verified; nil when it is not pending verification
+	VerifyingSince *time.Time `json:"verifying_since,omitempty" db:"verifying_since"`
+	// AlertCreatedAt is when Slide raised the alert, which can be long before its ticket
+	// was created; nil on mappings saved before it was recorded
+	AlertCreatedAt *time.Time `json:"alert_created_at,omitempty" db:"alert_created_at"`
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	ClosedAt  *time.Time `json:"closed_at,omitempty" db:"closed_at"`
 }
@@ -286,11 +362,475 @@ type TicketingConfig struct {
 	PriorityName    string `json:"priority_name" db:"priority_name"`
 	TypeID          int    `json:"type_id" db:"type_id"`
 	TypeName        string `json:"type_name" db:"type_name"`
+	// SubTypeName and ItemName are only ever set by a routing rule
+	SubTypeName     string `json:"subtype_name,omitempty" db:"-"`
+	ItemName        string `json:"item_name,omitempty" db:"-"`
 	TicketSummary   string `json:"ticket_summary" db:"ticket_summary"`
 	TicketTemplate  string `json:"ticket_template" db:"ticket_template"`
 	AutoAssignTech  bool   `json:"auto_assign_tech" db:"auto_assign_tech"`
 	TechnicianID    *int   `json:"technician_id,omitempty" db:"technician_id"`
 	TechnicianName  string `json:"technician_name" db:"technician_name"`
+	AssignOwner     bool   `json:"assign_owner" db:"assign_owner"`
+	AssignResource  bool   `json:"assign_resource" db:"assign_resource"`
+	ScheduleTech    bool   `json:"schedule_tech" db:"schedule_tech"`
+	ScheduleMinutes int    `json:"schedule_minutes" db:"schedule_minutes"`
+	CorrelationMode          CorrelationMode `json:"correlation_mode" db:"correlation_mode"`
+	CorrelationWindowMinutes int             `json:"correlation_window_minutes" db:"correlation_window_minutes"`
+	ReopenMode               ReopenMode      `json:"reopen_mode" db:"reopen_mode"`
+	ReopenWindowDays         int             `json:"reopen_window_days" db:"reopen_window_days"`
+	// StormClientLimit caps new tickets per client per cycle; past it the client's alerts
+	// share one alert storm ticket. StormTotalLimit trips the circuit breaker once that many
+	// tickets have been opened in a cycle. Zero turns either off.
+	StormClientLimit int `json:"storm_client_limit" db:"storm_client_limit"`
+	StormTotalLimit  int `json:"storm_total_limit" db:"storm_total_limit"`
+	// StorageResolvePercent is the used-storage percentage a device must drop below
+	// for its storage alerts to resolve automatically
+	StorageResolvePercent int `json:"storage_resolve_percent" db:"storage_resolve_percent"`
+	// SyncPolicy decides which side may close a ticket and its Slide alerts, unless
+	// the client mapping overrides it
+	SyncPolicy SyncPolicy `json:"sync_policy" db:"sync_policy"`
 	CreatedAt       time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
-}
\ No newline at end of file
+}
+
+// CorrelationMode decides which alerts share a ticket: alerts of the same type
+// on the same agent, device or client within the correlation window
+type CorrelationMode string
+
+const (
+	CorrelationOff    CorrelationMode = "off"
+	CorrelationAgent  CorrelationMode = "agent"
+	CorrelationDevice CorrelationMode = "device"
+	CorrelationClient CorrelationMode = "client"
+)
+
+// ReopenMode decides what happens when an agent raises an alert of the same type
+// within the reopen window of its last such ticket closing
+type ReopenMode string
+
+const (
+	// ReopenOff opens a new ticket with no reference to the old one
+	ReopenOff ReopenMode = "off"
+	// ReopenTicket reopens the closed ticket and notes the new alert on it
+	ReopenTicket ReopenMode = "reopen"
+	// ReopenLink opens a new ticket that references the closed one
+	ReopenLink ReopenMode = "link"
+)
+
+// SyncPolicy decides whether closing a ticket closes its Slide alerts and resolving
+// an alert in Slide closes its ticket
+type SyncPolicy string
+
+const (
+	// SyncTwoWay closes each side when the other closes
+	SyncTwoWay SyncPolicy = "two_way"
+	// SyncSlideAuthoritative only lets Slide close things: a ticket closed while its
+	// alert is still open in Slide is reopened
+	SyncSlideAuthoritative SyncPolicy = "slide"
+	// SyncConnectWiseAuthoritative only lets ConnectWise close things: alerts resolved
+	// in Slide are noted on the ticket, which stays open until a technician closes it
+	SyncConnectWiseAuthoritative SyncPolicy = "connectwise"
+	// SyncRequireBackup closes the Slide alert with its ticket only if the agent has had
+	// a successful backup since the alert; otherwise the ticket is reopened
+	SyncRequireBackup SyncPolicy = "require_backup"
+)
+
+// Valid reports whether p is one of the known sync policies
+func (p SyncPolicy) Valid() bool {
+	switch p {
+	case SyncTwoWay, SyncSlideAuthoritative, SyncConnectWiseAuthoritative, SyncRequireBackup:
+		return true
+	}
+	return false
+}
+
+// RoutingAction is what a matching routing rule does with an alert
+type RoutingAction string
+
+const (
+	RouteTicket RoutingAction = "route"
+	RouteIgnore RoutingAction = "ignore"
+)
+
+// RoutingRule sends matching alerts to a board, status, priority, type and owner other
+// than the ticketing configuration's, or ignores them. Rules are tried in Position order
+// and the first match wins. Empty conditions match anything; empty ticket fields keep the
+// configuration's value.
+type RoutingRule struct {
+	ID       int           `json:"id" db:"id"`
+	Position int           `json:"position" db:"position"`
+	Name     string        `json:"name" db:"name"`
+	Enabled  bool          `json:"enabled" db:"enabled"`
+	Action   RoutingAction `json:"action" db:"action"`
+
+	MatchAlertType string `json:"match_alert_type" db:"match_alert_type"`
+	MatchClientID  string `json:"match_client_id" db:"match_client_id"`
+	MatchCompanyID int    `json:"match_company_id" db:"match_company_id"`
+	MatchDevice    string `json:"match_device" db:"match_device"`
+	MatchAgentName string `json:"match_agent_name" db:"match_agent_name"`
+	MatchMessage   string `json:"match_message" db:"match_message"`
+
+	BoardID             int    `json:"board_id" db:"board_id"`
+	BoardName           string `json:"board_name" db:"board_name"`
+	StatusName          string `json:"status_name" db:"status_name"`
+	PriorityName        string `json:"priority_name" db:"priority_name"`
+	TypeName            string `json:"type_name" db:"type_name"`
+	SubTypeName         string `json:"subtype_name" db:"subtype_name"`
+	ItemName            string `json:"item_name" db:"item_name"`
+	OwnerID             *int   `json:"owner_id,omitempty" db:"owner_id"`
+	OwnerName           string `json:"owner_name" db:"owner_name"`
+	SummaryTemplate     string `json:"summary_template" db:"summary_template"`
+	DescriptionTemplate string `json:"description_template" db:"description_template"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// RoutingFacts are the alert details routing rules match on
+type RoutingFacts struct {
+	AlertType  string `json:"alert_type"`
+	ClientID   string `json:"client_id"`
+	CompanyID  int    `json:"company_id"`
+	DeviceID   string `json:"device_id"`
+	DeviceName string `json:"device_name"`
+	AgentName  string `json:"agent_name"`
+	Message    string `json:"message"`
+}
+
+// Validate checks the rule has an action, a usable message pattern and, if it moves
+// tickets to another board, a status on that board
+func (r *RoutingRule) Validate() error {
+	switch r.Action {
+	case RouteTicket, RouteIgnore:
+	default:
+		return fmt.Errorf("unknown action %q", r.Action)
+	}
+	if r.MatchMessage != "" {
+		if _, err := regexp.Compile(r.MatchMessage); err != nil {
+			return fmt.Errorf("invalid message pattern: %w", err)
+		}
+	}
+	if r.Action == RouteTicket && r.BoardName != "" && r.StatusName == "" {
+		return fmt.Errorf("a status is required when the rule sets a board")
+	}
+	return nil
+}
+
+// Matches reports whether every condition the rule sets holds for facts.
+// The device condition accepts either the device's ID or its name.
+func (r *RoutingRule) Matches(facts RoutingFacts) bool {
+	if r.MatchAlertType != "" && !strings.EqualFold(r.MatchAlertType, facts.AlertType) {
+		return false
+	}
+	if r.MatchClientID != "" && r.MatchClientID != facts.ClientID {
+		return false
+	}
+	if r.MatchCompanyID != 0 && r.MatchCompanyID != facts.CompanyID {
+		return false
+	}
+	if r.MatchDevice != "" && r.MatchDevice != facts.DeviceID && !strings.EqualFold(r.MatchDevice, facts.DeviceName) {
+		return false
+	}
+	if r.MatchAgentName != "" && !strings.EqualFold(r.MatchAgentName, facts.AgentName) {
+		return false
+	}
+	if r.MatchMessage != "" {
+		pattern, err := regexp.Compile(r.MatchMessage)
+		if err != nil || !pattern.MatchString(facts.Message) {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply returns config with the ticket fields the rule sets replaced
+func (r *RoutingRule) Apply(config TicketingConfig) TicketingConfig {
+	if r.BoardName != "" {
+		config.BoardID, config.BoardName = r.BoardID, r.BoardName
+		// Types, subtypes and items belong to a board, so don't carry the old board's over
+		config.TypeName, config.SubTypeName, config.ItemName = "", "", ""
+	}
+	if r.StatusName != "" {
+		config.StatusName = r.StatusName
+	}
+	if r.PriorityName != "" {
+		config.PriorityName = r.PriorityName
+	}
+	if r.TypeName != "" {
+		config.TypeName = r.TypeName
+	}
+	if r.SubTypeName != "" {
+		config.SubTypeName = r.SubTypeName
+	}
+	if r.ItemName != "" {
+		config.ItemName = r.ItemName
+	}
+	if r.OwnerID != nil {
+		ownerID := *r.OwnerID
+		config.AutoAssignTech = true
+		config.TechnicianID = &ownerID
+		config.TechnicianName = r.OwnerName
+		config.AssignOwner = true
+		config.AssignResource = false
+		config.ScheduleTech = false
+	}
+	if r.SummaryTemplate != "" {
+		config.TicketSummary = r.SummaryTemplate
+	}
+	if r.DescriptionTemplate != "" {
+		config.TicketTemplate = r.DescriptionTemplate
+	}
+	return config
+}
+
+// MatchRoutingRule returns the first enabled rule matching facts, or nil
+func MatchRoutingRule(rules []RoutingRule, facts RoutingFacts) *RoutingRule {
+	for i := range rules {
+		if rules[i].Enabled && rules[i].Matches(facts) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+// BoardResolvedStatus is the status an admin chose for auto-resolved tickets on a board
+type BoardResolvedStatus struct {
+	BoardID    int       `json:"board_id" db:"board_id"`
+	BoardName  string    `json:"board_name" db:"board_name"`
+	StatusID   int       `json:"status_id" db:"status_id"`
+	StatusName string    `json:"status_name" db:"status_name"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AlertTypePolicy holds back tickets for an alert type. A new alert waits GraceMinutes
+// for its problem to clear before it is ticketed. An agent that has raised FlapThreshold
+// alerts of the type within FlapWindowHours is flapping, and waits FlapGraceMinutes instead.
+// Backup alerts only auto-close after VerifyBackups consecutive successful backups spanning
+// at least VerifyHours; until then the ticket sits in VerifyStatus.
+type AlertTypePolicy struct {
+	AlertType        string    `json:"alert_type" db:"alert_type"`
+	GraceMinutes     int       `json:"grace_minutes" db:"grace_minutes"`
+	FlapThreshold    int       `json:"flap_threshold" db:"flap_threshold"`
+	FlapWindowHours  int       `json:"flap_window_hours" db:"flap_window_hours"`
+	FlapGraceMinutes int       `json:"flap_grace_minutes" db:"flap_grace_minutes"`
+	VerifyBackups    int       `json:"verify_backups" db:"verify_backups"`
+	VerifyHours      int       `json:"verify_hours" db:"verify_hours"`
+	VerifyStatus     string    `json:"verify_status" db:"verify_status"`
+	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// VerifiesBackups reports whether the policy asks for more than one successful backup before auto-closing
+func (p *AlertTypePolicy) VerifiesBackups() bool {
+	return p.VerifyBackups > 1 || p.VerifyHours > 0
+}
+
+// Lease is a time-limited lock held by one running instance
+type Lease struct {
+	Name       string    `json:"name" db:"name"`
+	Holder     string    `json:"holder" db:"holder"`
+	AcquiredAt time.Time `json:"acquired_at" db:"acquired_at"`
+	RenewedAt  time.Time `json:"renewed_at" db:"renewed_at"`
+	ExpiresAt  time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// PendingTicket marks a ticket creation that was started but not yet recorded in
+// alert_ticket_mappings, so a crash between the two can be reconciled
+type PendingTicket struct {
+	AlertID   string    `json:"alert_id" db:"alert_id"`
+	CompanyID int       `json:"company_id" db:"company_id"`
+	// ClientID is the Slide client the alert was resolved to, for the adopted ticket's mapping
+	ClientID  string    `json:"client_id" db:"client_id"`
+	AgentID   string    `json:"agent_id" db:"agent_id"`
+	AlertType string    `json:"alert_type" db:"alert_type"`
+	CorrelationKey string `json:"correlation_key,omitempty" db:"correlation_key"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// MaintenanceScope is what a maintenance window covers
+type MaintenanceScope string
+
+const (
+	MaintenanceClient MaintenanceScope = "client"
+	MaintenanceDevice MaintenanceScope = "device"
+	MaintenanceAgent  MaintenanceScope = "agent"
+)
+
+// MaintenanceRecurrence is how often a maintenance window repeats
+type MaintenanceRecurrence string
+
+const (
+	RecurOnce   MaintenanceRecurrence = "once"
+	RecurDaily  MaintenanceRecurrence = "daily"
+	RecurWeekly MaintenanceRecurrence = "weekly"
+)
+
+// MaintenanceTimeFormat is the wall-clock format of MaintenanceWindow.StartsAt
+const MaintenanceTimeFormat = "2006-01-02T15:04"
+
+// MaintenanceWindow stops alerts for a client, device or agent being ticketed while it
+// is active. StartsAt is wall-clock time in Timezone; a recurring window repeats from
+// then every day, or every week on the same weekday.
+type MaintenanceWindow struct {
+	ID              int                   `json:"id" db:"id"`
+	Name            string                `json:"name" db:"name"`
+	Scope           MaintenanceScope      `json:"scope" db:"scope"`
+	ScopeID         string                `json:"scope_id" db:"scope_id"`
+	Timezone        string                `json:"timezone" db:"timezone"`
+	StartsAt        string                `json:"starts_at" db:"starts_at"`
+	DurationMinutes int                   `json:"duration_minutes" db:"duration_minutes"`
+	Recurrence      MaintenanceRecurrence `json:"recurrence" db:"recurrence"`
+	CreatedAt       time.Time             `json:"created_at" db:"created_at"`
+}
+
+// Validate checks the window is complete and its timezone and start time parse
+func (w *MaintenanceWindow) Validate() error {
+	switch w.Scope {
+	case MaintenanceClient, MaintenanceDevice, MaintenanceAgent:
+	default:
+		return fmt.Errorf("unknown scope %q", w.Scope)
+	}
+	if w.ScopeID == "" {
+		return fmt.Errorf("scope_id is required")
+	}
+	if w.DurationMinutes <= 0 {
+		return fmt.Errorf("duration_minutes must be positive")
+	}
+	_, err := w.ActiveAt(time.Now())
+	return err
+}
+
+// ActiveAt reports whether the window covers t
+func (w *MaintenanceWindow) ActiveAt(t time.Time) (bool, error) {
+	loc, err := time.LoadLocation(w.Timezone)
+	if err != nil {
+		return false, fmt.Errorf("invalid timezone %q: %w", w.Timezone, err)
+	}
+	start, err := time.ParseInLocation(MaintenanceTimeFormat, w.StartsAt, loc)
+	if err != nil {
+		return false, fmt.Errorf("invalid start time %q: %w", w.StartsAt, err)
+	}
+	duration := time.Duration(w.DurationMinutes) * time.Minute
+
+	switch w.Recurrence {
+	case RecurOnce:
+		return !t.Before(start) && t.Before(start.Add(duration)), nil
+	case RecurDaily, RecurWeekly:
+	default:
+		return false, fmt.Errorf("unknown recurrence %q", w.Recurrence)
+	}
+
+	// Check today's occurrence and any from earlier days long enough to still be running.
+	// Building each from the wall clock keeps the start time fixed across DST changes.
+	local := t.In(loc)
+	for days := 0; days <= int(duration/(24*time.Hour))+1; days++ {
+		day := local.AddDate(0, 0, -days)
+		if w.Recurrence == RecurWeekly && day.Weekday() != start.Weekday() {
+			continue
+		}
+
+		occurrence := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		if occurrence.Before(start) {
+			continue
+		}
+		if !t.Before(occurrence) && t.Before(occurrence.Add(duration)) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// MaintenanceAlert records an alert that was not ticketed because a window was active
+type MaintenanceAlert struct {
+	AlertID    string    `json:"alert_id" db:"alert_id"`
+	WindowID   int       `json:"window_id" db:"window_id"`
+	AgentID    string    `json:"agent_id" db:"agent_id"`
+	AlertType  string    `json:"alert_type" db:"alert_type"`
+	RecordedAt time.Time `json:"recorded_at" db:"recorded_at"`
+}
+
+// EscalationStep acts on alert tickets still open AfterHours after they were created:
+// raising the priority, reassigning the owner and/or adding a note. Each step fires
+// at most once per ticket.
+type EscalationStep struct {
+	ID      int    `json:"id" db:"id"`
+	Name    string `json:"name" db:"name"`
+	Enabled bool   `json:"enabled" db:"enabled"`
+	// AlertType limits the step to tickets for that alert type; empty applies to all
+	AlertType    string    `json:"alert_type" db:"alert_type"`
+	AfterHours   int       `json:"after_hours" db:"after_hours"`
+	PriorityName string    `json:"priority_name" db:"priority_name"`
+	OwnerID      *int      `json:"owner_id,omitempty" db:"owner_id"`
+	OwnerName    string    `json:"owner_name" db:"owner_name"`
+	Note         string    `json:"note" db:"note"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Validate checks the step has a delay and does something when it fires
+func (s *EscalationStep) Validate() error {
+	if s.AfterHours <= 0 {
+		return fmt.Errorf("after_hours must be positive")
+	}
+	if s.PriorityName == "" && s.OwnerID == nil && s.Note == "" {
+		return fmt.Errorf("a step must set a priority, an owner or a note")
+	}
+	return nil
+}
+
+// AppliesTo reports whether the step covers a ticket for any of alertTypes
+func (s *EscalationStep) AppliesTo(alertTypes []string) bool {
+	if s.AlertType == "" {
+		return true
+	}
+	for _, alertType := range alertTypes {
+		if strings.EqualFold(alertType, s.AlertType) {
+			return true
+		}
+	}
+	return false
+}
+
+// TicketEscalation records an escalation step having fired on a ticket
+type TicketEscalation struct {
+	TicketID int       `json:"ticket_id" db:"ticket_id"`
+	StepID   int       `json:"step_id" db:"step_id"`
+	FiredAt  time.Time `json:"fired_at" db:"fired_at"`
+}
+
+// WorkAction is the kind of retryable operation held in the work queue
+type WorkAction string
+
+const (
+	// WorkCreateTicket creates the ticket for the alert in Payload
+	WorkCreateTicket WorkAction = "create_ticket"
+	// WorkCloseTicket closes TicketID and then the alert's ticket mapping
+	WorkCloseTicket WorkAction = "close_ticket"
+	// WorkCloseAlert closes the Slide alert; a non-zero TicketID means the
+	// ticket is already closed, so the mapping is closed too
+	WorkCloseAlert WorkAction = "close_alert"
+)
+
+// Work item statuses
+const (
+	WorkPending = "pending"
+	// WorkFailed items have used up their attempts (or hit a permanent error) and wait for an admin
+	WorkFailed = "failed"
+	// WorkSkipped is a ticket creation an admin discarded. It is kept so the alert isn't
+	// ticketed again, until the alert resolves or the item is retried.
+	WorkSkipped = "skipped"
+)
+
+// WorkItem is a failed operation waiting to be retried
+type WorkItem struct {
+	ID            int        `json:"id" db:"id"`
+	Action        WorkAction `json:"action" db:"action"`
+	AlertID       string     `json:"alert_id" db:"alert_id"`
+	TicketID      int        `json:"ticket_id" db:"ticket_id"`
+	Payload       string     `json:"payload,omitempty" db:"payload"`
+	Status        string     `json:"status" db:"status"`
+	Attempts      int        `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError     string     `json:"last_error" db:"last_error"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}